@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// accountSuffixes lists the variable suffixes of the configured sending
+// accounts: "" for GMAIL_EMAIL itself, then "_2", "_3", and so on for each
+// numbered GMAIL_EMAIL_<n> block, stopping at the first missing number.
+func accountSuffixes(lookup func(string) string) []string {
+	suffixes := []string{""}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf("_%d", n)
+		if lookup("GMAIL_EMAIL"+suffix) == "" {
+			return suffixes
+		}
+		suffixes = append(suffixes, suffix)
+	}
+}
+
+// senderConfigs returns one copy of config per sending account, each with
+// that account's address and credentials, so certificate emails can be
+// spread across accounts to stay under Gmail's daily quota. The first is
+// config itself.
+func senderConfigs(config EmailConfig) []EmailConfig {
+	senders := []EmailConfig{config}
+	for _, suffix := range accountSuffixes(os.Getenv)[1:] {
+		sender := config
+		sender.Email = os.Getenv("GMAIL_EMAIL" + suffix)
+		sender.AppPassword = os.Getenv("GMAIL_APP_PASSWORD" + suffix)
+		sender.OAuth = oauthFromEnv(suffix)
+		senders = append(senders, sender)
+	}
+	return senders
+}
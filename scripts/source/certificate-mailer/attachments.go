@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// attachmentsTooLarge is returned instead of sending a message whose
+// attached and embedded files add up to more than -attachment-max-mb.
+type attachmentsTooLarge struct {
+	size, max int64
+}
+
+func (e *attachmentsTooLarge) Error() string {
+	return fmt.Sprintf("attachments total %s, over the -attachment-max-mb limit of %s", formatSize(e.size), formatSize(e.max))
+}
+
+// checkAttachmentSize totals the files that will go into one message and
+// errors if they exceed max bytes. The certificate PDFs are rarely large;
+// the limit is there for a -logo, -background, or -certificate-logo that
+// would bloat every message until the mail server rejects it. A max of 0
+// disables the check.
+func checkAttachmentSize(paths []string, max int64) error {
+	if max <= 0 {
+		return nil
+	}
+	var total int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+	}
+	if total > max {
+		return &attachmentsTooLarge{size: total, max: max}
+	}
+	return nil
+}
+
+func formatSize(bytes int64) string {
+	if bytes < 1<<20 {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
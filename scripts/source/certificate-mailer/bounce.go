@@ -0,0 +1,34 @@
+package main
+
+import "regexp"
+
+// bouncedError is a send the mail server refused with a permanent (5xx)
+// reply, such as "550 5.1.1 no such user". Resending to the same address
+// won't help, so the roster needs correcting.
+type bouncedError struct {
+	err error
+}
+
+func (e *bouncedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *bouncedError) Unwrap() error {
+	return e.err
+}
+
+// permanentReply matches the server reply gomail quotes when it fails to
+// send a message, as in "could not send email 1: 550 5.1.1 ...". gomail
+// formats the reply into its error rather than wrapping it, so the code is
+// read from the text. Failures before any message, such as a 535 login
+// rejection, aren't bounces and read differently.
+var permanentReply = regexp.MustCompile(`could not send email \d+: 5\d\d\b`)
+
+// classifySendError wraps an SMTP send error in bouncedError if the server
+// rejected the message permanently.
+func classifySendError(err error) error {
+	if permanentReply.MatchString(err.Error()) {
+		return &bouncedError{err: err}
+	}
+	return err
+}
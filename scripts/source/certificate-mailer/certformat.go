@@ -0,0 +1,23 @@
+package main
+
+import "github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+
+// certificateFormats are the -format values: the PDF, or a standalone HTML
+// page for members who can't open one, e.g. on a phone.
+var certificateFormats = []string{"pdf", "html"}
+
+// generateCertificate renders a certificate in the -format.
+func generateCertificate(format string, config lib.CertificateConfig, attendee lib.Attendee, event lib.EventInfo, outputDir string) (string, error) {
+	if format == "html" {
+		return lib.GenerateHTMLCertificate(config, attendee, event, outputDir)
+	}
+	return lib.GenerateCertificate(config, attendee, event, outputDir)
+}
+
+// certificateFilename is the name generateCertificate saves under.
+func certificateFilename(format string, attendee lib.Attendee, event lib.EventInfo) string {
+	if format == "html" {
+		return lib.HTMLCertificateFilename(attendee, event)
+	}
+	return lib.CertificateFilename(attendee, event)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// runCheck loads every input file, matches attendees to the roster, and
+// prints a validation report. Nothing is generated or sent. It returns false
+// if any problem was found.
+func runCheck(rosterPath string, rosterFormat lib.NameFormat, attendancePath string, calendarPaths []string, credentialSource string, opts lib.ReadOptions) bool {
+	problems := 0
+	report := func(format string, args ...any) {
+		problems++
+		fmt.Printf("  PROBLEM: "+format+"\n", args...)
+	}
+
+	fmt.Println("Credentials:")
+	env, err := readCredentials(credentialSource)
+	if err != nil {
+		report("cannot read credentials: %v", err)
+	} else {
+		before := problems
+		lookup := func(key string) string { return env[key] }
+		for _, s := range accountSuffixes(lookup) {
+			if env["GMAIL_EMAIL"+s] == "" {
+				report("GMAIL_EMAIL is not set")
+			} else if env["GMAIL_APP_PASSWORD"+s] == "" && (env["GMAIL_OAUTH_CLIENT_ID"+s] == "" || env["GMAIL_OAUTH_CLIENT_SECRET"+s] == "" || env["GMAIL_OAUTH_REFRESH_TOKEN"+s] == "") {
+				report("neither GMAIL_APP_PASSWORD%s nor the GMAIL_OAUTH_*%s variables are set", s, s)
+			}
+		}
+		if problems == before {
+			fmt.Println("  ok")
+		}
+	}
+
+	fmt.Printf("Roster (%s):\n", rosterPath)
+	roster, err := lib.ReadRoster(rosterPath, rosterFormat, opts)
+	if err != nil {
+		report("%s", describeReadError(err))
+	} else {
+		fmt.Printf("  %d entries\n", len(roster))
+		var names []string
+		for name := range roster {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := mail.ParseAddress(roster[name].Email); err != nil {
+				report("invalid email for %s: %q", pii.Name(name), pii.Email(roster[name].Email))
+			}
+		}
+	}
+
+	fmt.Printf("Attendance (%s):\n", attendancePath)
+	attendees, err := lib.ReadAttendance(attendancePath, opts)
+	if err != nil {
+		report("%s", describeReadError(err))
+	} else {
+		fmt.Printf("  %d attendees\n", len(attendees))
+		if roster != nil {
+			attendees = lib.MatchAttendeesWithEmails(attendees, roster)
+			for _, attendee := range lib.FindUnmatchedAttendees(attendees) {
+				report("no roster email for %s", pii.Name(attendee.Name))
+			}
+		}
+	}
+
+	fmt.Printf("Calendar (%s):\n", strings.Join(calendarPaths, ", "))
+	events, err := lib.ReadCalendars(calendarPaths, opts)
+	if err != nil {
+		report("%s", describeReadError(err))
+	} else {
+		fmt.Printf("  %d events\n", len(events))
+		if len(events) == 0 {
+			report("no valid events found")
+		}
+		for _, event := range events {
+			if _, err := lib.ParseFlexibleDate(event.Date); err != nil {
+				report("unparseable date %q for %q", event.Date, event.Topic)
+			}
+		}
+	}
+
+	if problems > 0 {
+		fmt.Fprintf(os.Stderr, "\nCheck failed: %d problem(s) found\n", problems)
+		return false
+	}
+	fmt.Println("\nAll checks passed")
+	return true
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// checksumFile sits beside the certificates and records the input hash each
+// one was generated from.
+const checksumFile = ".checksums.json"
+
+// certificateChecksum hashes everything that ends up on an attendee's
+// certificate, including the contents of the signature, background, logo,
+// and font files. The issue date is left out when it defaults to today, so
+// a rerun on a later day still recognizes an unchanged certificate (which
+// keeps its original date).
+func certificateChecksum(config lib.CertificateConfig, attendee lib.Attendee, event lib.EventInfo) (string, error) {
+	signature, err := fileContents(config.SignaturePath)
+	if err != nil {
+		return "", err
+	}
+	// A gradient is fully described by the config itself
+	var background []byte
+	if !strings.HasPrefix(config.Background, "gradient:") {
+		background, err = fileContents(config.Background)
+		if err != nil {
+			return "", err
+		}
+	}
+	// The logo is optional, so a missing one hashes as nothing, as it's drawn
+	logoPath := config.LogoPath
+	if logoPath == "" {
+		logoPath = lib.DefaultLogoPath
+	}
+	logo, err := fileContents(logoPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	// Core fonts are named, not read from a file
+	var font []byte
+	if strings.EqualFold(filepath.Ext(config.FontFamily), ".ttf") {
+		font, err = fileContents(config.FontFamily)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Config     lib.CertificateConfig
+		Signature  [sha256.Size]byte
+		Background [sha256.Size]byte
+		Font       [sha256.Size]byte
+		Logo       [sha256.Size]byte
+		Name       string
+		Title      string
+		PDH        float64
+		Event      lib.EventInfo
+	}{config, sha256.Sum256(signature), sha256.Sum256(background), sha256.Sum256(font), sha256.Sum256(logo), attendee.Name, attendee.Title, attendee.PDH, event})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// fileContents reads an optional input file; an empty path reads as nothing.
+func fileContents(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+// checksums maps certificate filenames to the input hash they were generated
+// from.
+type checksums map[string]string
+
+// readChecksums loads the checksum file in dir. A missing or unreadable file
+// just means nothing can be skipped.
+func readChecksums(dir string) checksums {
+	sums := make(checksums)
+	data, err := os.ReadFile(filepath.Join(dir, checksumFile))
+	if err != nil {
+		return sums
+	}
+	if err := json.Unmarshal(data, &sums); err != nil {
+		return make(checksums)
+	}
+	return sums
+}
+
+// unchanged reports whether path exists and was generated from sum.
+func (c checksums) unchanged(path, sum string) bool {
+	if c[filepath.Base(path)] != sum {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (c checksums) write(dir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, checksumFile), append(data, '\n'), 0644)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// keyringService is the service name credentials are stored under in the OS
+// keyring, with GMAIL_EMAIL as the account.
+const keyringService = "lrec-certificate-mailer"
+
+// readCredentials returns the GMAIL_* settings from the chosen source:
+//
+//	env          the ../.env file (the default)
+//	env:NAME     the ../.env.NAME file, as selected by -env
+//	file:PATH    a dotenv-format secrets file kept outside the repo
+//	keyring      the app password from the OS keyring; GMAIL_EMAIL still
+//	             comes from ../.env or the environment
+func readCredentials(source string) (map[string]string, error) {
+	switch {
+	case source == "" || source == "env":
+		return godotenv.Read("../.env")
+
+	case strings.HasPrefix(source, "env:"):
+		return godotenv.Read("../.env." + strings.TrimPrefix(source, "env:"))
+
+	case strings.HasPrefix(source, "file:"):
+		path := strings.TrimPrefix(source, "file:")
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		// Windows doesn't report Unix permission bits
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+			log.Printf("Warning: %s is readable by other users (mode %o); consider chmod 600", path, info.Mode().Perm())
+		}
+		return godotenv.Read(path)
+
+	case source == "keyring":
+		env, err := godotenv.Read("../.env")
+		if err != nil {
+			env = make(map[string]string)
+		}
+		email := env["GMAIL_EMAIL"]
+		if email == "" {
+			email = os.Getenv("GMAIL_EMAIL")
+		}
+		if email == "" {
+			return nil, fmt.Errorf("GMAIL_EMAIL must be set to look up the keyring entry")
+		}
+		password, err := keyringPassword(email)
+		if err != nil {
+			return nil, err
+		}
+		env["GMAIL_EMAIL"] = email
+		env["GMAIL_APP_PASSWORD"] = password
+		return env, nil
+	}
+	return nil, fmt.Errorf("unknown credential source %q (use env, file:PATH, or keyring)", source)
+}
+
+// validEnvName reports whether name can select a ../.env.NAME file without
+// reaching outside the scripts directory.
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// loadCredentials puts the credentials into the environment. Like
+// godotenv.Load, variables that are already set win.
+func loadCredentials(source string) error {
+	env, err := readCredentials(source)
+	if err != nil {
+		return err
+	}
+	for key, value := range env {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
+}
+
+// keyringPassword reads the app password with the platform's keyring tool:
+// security on macOS and secret-tool (libsecret) on Linux.
+func keyringPassword(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	default:
+		return "", fmt.Errorf("keyring credentials are not supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the keyring: %v", keyringService, err)
+	}
+	password := strings.TrimSpace(string(out))
+	if password == "" {
+		return "", fmt.Errorf("no keyring entry for %s under %s", account, keyringService)
+	}
+	return password, nil
+}
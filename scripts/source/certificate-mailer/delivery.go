@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// delivery generates and sends one run's certificates. It carries where
+// each outcome is recorded and the running counts the summary reports.
+type delivery struct {
+	ctx context.Context
+	// stop ends the run early; everyone not yet sent is recorded as unsent
+	stop        context.CancelCauseFunc
+	timer       *runTimer
+	opts        options
+	event       lib.EventInfo
+	emailConfig EmailConfig
+	// senders are the accounts certificate emails rotate through
+	senders         []EmailConfig
+	certificateNote *template.Template
+	results         *sendLog
+	report          *runReport
+	// throttle paces sends under -rate, nil for no limit
+	throttle  <-chan time.Time
+	outputDir string
+
+	generated, sent int
+	// attempted counts throttled sends; sendIndex picks the next sender
+	attempted, sendIndex int
+	// unsent counts attendees left when the -deadline passed or the run stopped
+	unsent   int
+	failures []runFailure
+	// bounced are addresses the mail server rejected for good
+	bounced []lib.Attendee
+}
+
+// run generates a certificate for each attendee and delivers it: one email
+// each, one per shared address under -group-by-email, or a single ZIP with
+// -zip-to.
+func (d *delivery) run(attendees []lib.Attendee) {
+	o := d.opts
+
+	// With -group-by-email, certificates for a shared address are held until
+	// every attendee has been generated and then sent together
+	var shared map[string]int
+	if o.groupByEmail {
+		shared = sharedEmails(attendees)
+	}
+	households := make(map[string][]zipEntry)
+	var householdOrder []string
+	var bundle []zipEntry
+	issued := make(map[string]registryEntry)
+	var numbers *certificateNumbers
+	if o.numberPrefix != "" {
+		var err error
+		numbers, err = loadCertificateNumbers(o.registryPath, o.numberPrefix)
+		if err != nil {
+			log.Fatalf("Error reading registry for -number-prefix: %v", err)
+		}
+	}
+	sums := readChecksums(d.outputDir)
+	for _, attendee := range attendees {
+		if d.ctx.Err() != nil {
+			d.recordUnsent(attendee, "")
+			continue
+		}
+		attendeeConfig := o.certConfig
+		var err error
+		attendeeConfig.Note, err = renderNote(d.certificateNote, attendee)
+		if err != nil {
+			log.Printf("Error rendering certificate note for %s: %s", pii.Name(attendee.Name), pii.Scrub(err.Error(), attendee))
+			d.results.Record(attendee, "", "", "generate-failed", err)
+			d.report.Record(attendee, d.event, "", "generate-failed", err)
+			d.failures = append(d.failures, runFailure{Attendee: attendee, Step: "generate", Err: err})
+			continue
+		}
+
+		id := lib.CertificateID(attendee, d.event)
+		if numbers != nil {
+			attendeeConfig.Number = numbers.assign(id)
+		}
+
+		// Reuse the PDF from an earlier run when nothing on it would change
+		filePath := filepath.Join(d.outputDir, certificateFilename(o.certificateFormat, attendee, d.event))
+		sum, err := certificateChecksum(attendeeConfig, attendee, d.event)
+		if err == nil && !o.force && sums.unchanged(filePath, sum) {
+			fmt.Printf("Certificate for %s is unchanged, not regenerating\n", pii.Name(attendee.Name))
+		} else {
+			generateStart := time.Now()
+			filePath, err = generateCertificate(o.certificateFormat, attendeeConfig, attendee, d.event, d.outputDir)
+			d.timer.generation(generateStart)
+			if err != nil {
+				log.Printf("Error generating certificate for %s: %s", pii.Name(attendee.Name), pii.Scrub(err.Error(), attendee))
+				d.results.Record(attendee, "", "", "generate-failed", err)
+				d.report.Record(attendee, d.event, "", "generate-failed", err)
+				d.failures = append(d.failures, runFailure{Attendee: attendee, Step: "generate", Err: err})
+				continue
+			}
+			if sum != "" {
+				sums[filepath.Base(filePath)] = sum
+			}
+			fmt.Printf("Generated certificate for %s\n", pii.Name(attendee.Name))
+		}
+		d.generated++
+		issued[id] = newRegistryEntry(attendee, d.event, attendeeConfig)
+		// Save a new number at once, so a run that dies partway can't give
+		// it to someone else next time
+		if numbers != nil && numbers.isNew(id) {
+			if err := exportRegistry(o.registryPath, map[string]registryEntry{id: issued[id]}); err != nil {
+				log.Fatalf("Error saving certificate number %s: %v", attendeeConfig.Number, err)
+			}
+		}
+
+		if o.zipTo != "" {
+			bundle = append(bundle, zipEntry{Attendee: attendee, Path: filePath})
+			d.results.Record(attendee, filePath, "", "zipped", nil)
+			d.report.Record(attendee, d.event, filePath, "zipped", nil)
+			continue
+		}
+
+		entry := zipEntry{Attendee: attendee, Path: filePath}
+		if key := emailKey(attendee.Email); shared[key] > 1 {
+			if len(households[key]) == 0 {
+				householdOrder = append(householdOrder, key)
+			}
+			households[key] = append(households[key], entry)
+			continue
+		}
+		d.deliver([]zipEntry{entry})
+	}
+
+	for _, key := range householdOrder {
+		d.deliver(households[key])
+	}
+
+	if err := sums.write(d.outputDir); err != nil {
+		log.Printf("Warning: could not save certificate checksums: %v", err)
+	}
+
+	if o.registryPath != "" {
+		if err := exportRegistry(o.registryPath, issued); err != nil {
+			log.Fatalf("Error exporting registry: %v", err)
+		}
+		fmt.Printf("Recorded %d certificates in %s\n", len(issued), o.registryPath)
+	}
+
+	if o.zipTo != "" {
+		d.deliverZip(bundle)
+	}
+}
+
+// recordUnsent records an attendee the -deadline left no time for, or who
+// was still to come when the run was stopped.
+func (d *delivery) recordUnsent(attendee lib.Attendee, certificatePath string) {
+	err := context.Cause(d.ctx)
+	if d.unsent == 0 {
+		log.Printf("Stopping: %v; recording everyone left as unsent", err)
+	}
+	d.unsent++
+	d.results.Record(attendee, certificatePath, "", "unsent", err)
+	d.report.Record(attendee, d.event, certificatePath, "unsent", err)
+	d.failures = append(d.failures, runFailure{Attendee: attendee, Step: "unsent", Err: err})
+}
+
+// deliver sends one email carrying the given certificates, which all go to
+// the same address, and records the outcome for each attendee.
+func (d *delivery) deliver(entries []zipEntry) {
+	if d.throttle != nil && entries[0].Attendee.Email != "" {
+		if d.attempted > 0 {
+			select {
+			case <-d.throttle:
+			case <-d.ctx.Done():
+			}
+		}
+		d.attempted++
+	}
+	if d.ctx.Err() != nil {
+		for _, entry := range entries {
+			d.recordUnsent(entry.Attendee, entry.Path)
+		}
+		return
+	}
+
+	sender := d.senders[d.sendIndex%len(d.senders)]
+	d.sendIndex++
+	sendStart := time.Now()
+	err := sendCertificateEmail(d.ctx, sender, d.event, entries)
+	d.timer.send(sendStart)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, pii.Name(entry.Attendee.Name))
+	}
+	var tooLarge *attachmentsTooLarge
+	if errors.As(err, &tooLarge) {
+		for _, entry := range entries {
+			log.Printf("Warning: skipping email to %s: %v", pii.Name(entry.Attendee.Name), err)
+			d.results.Record(entry.Attendee, entry.Path, sender.Email, "skipped-oversize", err)
+			d.report.Record(entry.Attendee, d.event, entry.Path, "skipped-oversize", err)
+			d.failures = append(d.failures, runFailure{Attendee: entry.Attendee, Step: "attachment", Err: err})
+		}
+		// Stop rather than exit, so the send log, checksums, and registry
+		// are still written for the emails already sent
+		if d.opts.attachmentPolicy == "error" {
+			d.stop(fmt.Errorf("-attachment-policy error: email to %s was too large", joinNames(names)))
+		}
+		return
+	}
+	var bounce *bouncedError
+	if errors.As(err, &bounce) {
+		for _, entry := range entries {
+			log.Printf("Email to %s bounced: %s", pii.Name(entry.Attendee.Name), pii.Scrub(err.Error(), entry.Attendee))
+			d.results.Record(entry.Attendee, entry.Path, sender.Email, "bounced", err)
+			d.report.Record(entry.Attendee, d.event, entry.Path, "bounced", err)
+			d.failures = append(d.failures, runFailure{Attendee: entry.Attendee, Step: "bounce", Err: err})
+			d.bounced = append(d.bounced, entry.Attendee)
+		}
+		return
+	}
+	if err != nil {
+		for _, entry := range entries {
+			log.Printf("Error sending email to %s: %s", pii.Name(entry.Attendee.Name), pii.Scrub(err.Error(), entry.Attendee))
+			d.results.Record(entry.Attendee, entry.Path, sender.Email, "send-failed", err)
+			d.report.Record(entry.Attendee, d.event, entry.Path, "send-failed", err)
+			d.failures = append(d.failures, runFailure{Attendee: entry.Attendee, Step: "send", Err: err})
+		}
+		return
+	}
+	d.sent++
+	fmt.Printf("Email sent to %s (%s)\n", joinNames(names), pii.Email(entries[0].Attendee.Email))
+	for _, entry := range entries {
+		d.results.Record(entry.Attendee, entry.Path, sender.Email, "sent", nil)
+		d.report.Record(entry.Attendee, d.event, entry.Path, "sent", nil)
+	}
+}
+
+// deliverZip bundles every generated certificate into one ZIP and emails it
+// to -zip-to.
+func (d *delivery) deliverZip(bundle []zipEntry) {
+	zipTo := d.opts.zipTo
+	zipPath, err := createCertificateZip(bundle, d.event, d.outputDir)
+	if err != nil {
+		log.Fatalf("Error creating certificate ZIP: %v", err)
+	}
+	fmt.Printf("Bundled %d certificates into %s\n", len(bundle), zipPath)
+
+	sendStart := time.Now()
+	err = sendCertificateZipEmail(d.ctx, d.emailConfig, d.event, zipTo, zipPath, len(bundle))
+	d.timer.send(sendStart)
+	if err != nil && d.ctx.Err() == nil {
+		log.Fatalf("Error sending ZIP to %s: %s", pii.Email(zipTo), pii.Scrub(err.Error(), lib.Attendee{Email: zipTo}))
+	}
+	if err != nil {
+		for _, entry := range bundle {
+			d.recordUnsent(entry.Attendee, zipPath)
+		}
+	} else {
+		d.sent = 1
+		fmt.Printf("Email sent to %s\n", pii.Email(zipTo))
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// estimateDuration is how long sending emails takes at rate per minute,
+// counting only the -rate pacing between messages. SMTP time comes on top,
+// and with no rate it is all there is, so 0 is returned.
+func estimateDuration(emails, rate int) time.Duration {
+	if rate <= 0 || emails <= 1 {
+		return 0
+	}
+	return time.Duration(emails-1) * (time.Minute / time.Duration(rate))
+}
+
+// quotaWarning explains how far a send would exceed the daily quota summed
+// over every sending account, or returns "" if it fits. quota 0 disables the
+// check.
+func quotaWarning(emails, accounts, quota int) string {
+	limit := quota * accounts
+	if quota <= 0 || emails <= limit {
+		return ""
+	}
+	days := (emails + limit - 1) / limit
+	return fmt.Sprintf("%d emails exceed the daily quota of %d (%d per account x %d account(s)); consider splitting the send over %d days with -only, or adding accounts", emails, limit, quota, accounts, days)
+}
+
+// printEstimate reports what a send would involve without doing it.
+func printEstimate(recipients, emails, accounts, rate, quota int) {
+	fmt.Printf("\nEstimate:\n")
+	fmt.Printf("  Recipients with valid emails: %d\n", recipients)
+	fmt.Printf("  Emails to send:               %d\n", emails)
+	fmt.Printf("  Sending accounts:             %d\n", accounts)
+	if rate > 0 {
+		fmt.Printf("  Rate:                         %d per minute\n", rate)
+		fmt.Printf("  Estimated completion:         %v plus SMTP time (about %s)\n", estimateDuration(emails, rate).Round(time.Second), time.Now().Add(estimateDuration(emails, rate)).Format("15:04"))
+	} else {
+		fmt.Printf("  Rate:                         unlimited (set -rate to pace sends)\n")
+	}
+	if warning := quotaWarning(emails, accounts, quota); warning != "" {
+		fmt.Printf("  WARNING: %s\n", warning)
+	} else if quota > 0 {
+		fmt.Printf("  Daily quota:                  %d of %d\n", emails, quota*accounts)
+	}
+}
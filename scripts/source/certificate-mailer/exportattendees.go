@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/mail"
+	"os"
+	"strconv"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// matchType says where an attendee's email came from: "roster" for a
+// usable roster address, "invalid" for a roster entry whose address doesn't
+// parse, and "unmatched" when no roster name matched.
+func matchType(attendee lib.Attendee) string {
+	if attendee.Email == "" {
+		return "unmatched"
+	}
+	if _, err := mail.ParseAddress(attendee.Email); err != nil {
+		return "invalid"
+	}
+	return "roster"
+}
+
+// exportAttendees writes the attendees, after name conversion and roster
+// matching, as a CSV for importing elsewhere. Names are in "First Last"
+// form with credentials in their own column.
+func exportAttendees(path string, attendees []lib.Attendee) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"Name", "Email", "Title", "PDH", "Match"})
+	for _, attendee := range attendees {
+		writer.Write([]string{
+			attendee.Name,
+			attendee.Email,
+			attendee.Title,
+			strconv.FormatFloat(attendee.PDH, 'g', -1, 64),
+			matchType(attendee),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
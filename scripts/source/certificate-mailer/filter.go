@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// attendeeList is a set of names and emails read from an -only or -exclude
+// file, one per line. Blank lines and lines starting with # are ignored.
+type attendeeList struct {
+	path    string
+	entries []string
+}
+
+func readAttendeeList(path string) (*attendeeList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &attendeeList{path: path}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Names may be listed as "Last, First" like the spreadsheets
+		if !strings.Contains(line, "@") {
+			line = lib.ConvertNameFormat(line)
+		}
+		list.entries = append(list.entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// entryMatches compares emails exactly and names ignoring case and spacing.
+func entryMatches(entry string, attendee lib.Attendee) bool {
+	if strings.Contains(entry, "@") {
+		return strings.EqualFold(entry, attendee.Email)
+	}
+	normalize := func(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+	return normalize(entry) == normalize(attendee.Name)
+}
+
+// filterAttendees keeps attendees that match the list (keep=true, for -only)
+// or that don't (keep=false, for -exclude). It logs how many were filtered
+// and warns about list entries that matched nobody.
+func filterAttendees(attendees []lib.Attendee, list *attendeeList, keep bool) []lib.Attendee {
+	used := make([]bool, len(list.entries))
+	var kept []lib.Attendee
+	for _, attendee := range attendees {
+		matched := false
+		for i, entry := range list.entries {
+			if entryMatches(entry, attendee) {
+				matched = true
+				used[i] = true
+			}
+		}
+		if matched == keep {
+			kept = append(kept, attendee)
+		}
+	}
+
+	for i, entry := range list.entries {
+		if !used[i] {
+			display := pii.Name(entry)
+			if strings.Contains(entry, "@") {
+				display = pii.Email(entry)
+			}
+			log.Printf("Warning: %s entry %s matched no attendee", list.path, display)
+		}
+	}
+
+	log.Printf("Filtered out %d of %d attendees using %s", len(attendees)-len(kept), len(attendees), list.path)
+	return kept
+}
+
+// filterByPDH keeps attendees who earned at least min PDH, for sessions
+// where partial attendance doesn't qualify. It logs each attendee left out
+// with the hours they earned, and how many were filtered.
+func filterByPDH(attendees []lib.Attendee, min float64) []lib.Attendee {
+	var kept []lib.Attendee
+	for _, attendee := range attendees {
+		if attendee.PDH >= min {
+			kept = append(kept, attendee)
+			continue
+		}
+		log.Printf("Excluding %s: earned %g PDH, under -min-pdh %g", pii.Name(attendee.Name), attendee.PDH, min)
+	}
+	log.Printf("Filtered out %d of %d attendees below %g PDH", len(attendees)-len(kept), len(attendees), min)
+	return kept
+}
+
+// dropZeroPDH leaves out attendees who earned no PDH, e.g. whose sign-in and
+// sign-out round to the same quarter hour, logging each one. A
+// certificate for 0 hours documents nothing, so this applies whatever
+// -min-pdh is.
+func dropZeroPDH(attendees []lib.Attendee) []lib.Attendee {
+	var kept []lib.Attendee
+	for _, attendee := range attendees {
+		if attendee.PDH > 0 {
+			kept = append(kept, attendee)
+			continue
+		}
+		log.Printf("Excluding %s: earned %g PDH, nothing to certify", pii.Name(attendee.Name), attendee.PDH)
+	}
+	return kept
+}
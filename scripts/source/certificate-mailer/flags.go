@@ -0,0 +1,372 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// options holds the command-line settings of a run, as read and checked by
+// parseFlags.
+type options struct {
+	subject                                           string
+	certConfig                                        lib.CertificateConfig
+	rate                                              int
+	smtpTimeout, deadline                             time.Duration
+	estimate                                          bool
+	dailyQuota                                        int
+	orientation                                       string
+	certificateFormat                                 string
+	failOnUnmatched                                   bool
+	zipTo                                             string
+	eventTopic                                        string
+	eventSpeaker, eventDate, eventLocation, eventTime string
+	checkOnly                                         bool
+	diffRoster                                        bool
+	rosterPath, attendancePath                        string
+	calendarPaths                                     pathList
+	sendLogPath                                       string
+	reportPath, resumePath                            string
+	dateFormat                                        string
+	summaryTo                                         string
+	unmatchedTo                                       string
+	listEvents                                        bool
+	maildir                                           string
+	noLock                                            bool
+	sendmailPath                                      string
+	sortBy                                            string
+	onlyPath, excludePath                             string
+	registryPath                                      string
+	numberPrefix                                      string
+	exportAttendeesPath                               string
+	assumeYes                                         bool
+	credentialSource                                  string
+	envName                                           string
+	live                                              bool
+	logoPath                                          string
+	surveyURL                                         string
+	textColor                                         string
+	reissueID                                         string
+	allowFuture                                       bool
+	lookbackDays                                      int
+	certificateNote, emailNote                        string
+	force                                             bool
+	byEvent                                           bool
+	maxNameLength                                     int
+	minPDH                                            float64
+	onBadName                                         string
+	attachmentMaxMB                                   float64
+	attachmentPolicy                                  string
+	rosterFormat                                      string
+	readOpts                                          lib.ReadOptions
+	greeting                                          string
+	fallbackGreeting                                  string
+	groupByEmail                                      bool
+	quiet                                             bool
+	smtpHost, smtpTLS, smtpCA                         string
+	smtpPort                                          int
+	smtpInsecure                                      bool
+	// flagEvent is the event given in full by -event-date and its companion
+	// flags, or nil when the calendar is read
+	flagEvent *lib.EventInfo
+}
+
+// parseFlags reads the command line and checks every setting, so a typo
+// fails with exit code 1 before any file is read or anything is sent.
+func parseFlags() options {
+	var o options
+
+	// Bad flags exit 1 rather than flag's default of 2, which means partial failure here
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+
+	flag.StringVar(&o.subject, "subject", defaultSubject, "Email subject template (fields: .Name, .Date, .Topic, .Speaker, .Fields.<roster column>)")
+	flag.StringVar(&o.certConfig.Design, "design", "classic", "Certificate design: "+strings.Join(lib.DesignNames(), ", "))
+	flag.StringVar(&o.certificateFormat, "format", "pdf", "Certificate file format: pdf, or html for a standalone printable page that opens on any phone")
+	flag.StringVar(&o.orientation, "orientation", "landscape", "Certificate page orientation: landscape or portrait")
+	flag.StringVar(&o.dateFormat, "date-format", "January 2, 2006", "Go layout for the event date on certificates and emails (default depends on -lang)")
+	flag.StringVar(&o.certConfig.Lang, "lang", "en", "Certificate language: "+strings.Join(lib.Languages(), ", "))
+	flag.StringVar(&o.certConfig.SignaturePath, "signature", "", "Signature image (PNG, JPEG, or GIF) to place above the signature line (optional)")
+	flag.BoolVar(&o.certConfig.AbstractPage, "abstract-page", false, "Add a second page with the session abstract when the calendar has an abstract column")
+	flag.StringVar(&o.certConfig.Watermark, "watermark", "", "Faint diagonal text behind each certificate, e.g. REISSUED for a reissued copy")
+	flag.StringVar(&o.certConfig.Background, "background", "", "PNG, JPEG, or GIF drawn behind each certificate, stretched to the page, or gradient:#RRGGBB,#RRGGBB")
+	flag.StringVar(&o.textColor, "text-color", "", "Certificate text and rule color as #RRGGBB, e.g. for brand colors or contrast with -background (default: the design's own colors)")
+	flag.StringVar(&o.certConfig.FontFamily, "font-family", "", "Certificate font: "+strings.Join(lib.FontFamilies(), ", ")+", or a .ttf file to embed (default times)")
+	flag.StringVar(&o.certConfig.LogoPath, "certificate-logo", "", "Logo image (PNG, JPEG, or GIF) at the top left of classic certificates (default "+lib.DefaultLogoPath+")")
+	flag.Float64Var(&o.certConfig.LogoWidth, "logo-width", 50, "Width in mm of the skyline logo on classic certificates; the header text follows it")
+	flag.BoolVar(&o.certConfig.NoLogoText, "no-logo-text", false, "Leave the club name header off classic certificates, for a logo that already shows it")
+	flag.StringVar(&o.certConfig.ProviderName, "provider-name", "", "Continuing education provider name for the accreditation block")
+	flag.StringVar(&o.certConfig.ProviderNumber, "provider-number", "", "Provider number required by the licensing board; adds the accreditation block at the bottom of the certificate")
+	flag.StringVar(&o.certConfig.AccreditationText, "accreditation-text", "", "Board approval statement printed in the accreditation block")
+	flag.StringVar(&o.certConfig.BoardName, "board-name", "", "Licensing board named in a line under the PDH statement, e.g. \"Arkansas State Board of Licensure for Professional Engineers and Professional Surveyors\" (omitted if empty)")
+	flag.StringVar(&o.certConfig.Signatory, "signatory", "", "Name printed below the signature line")
+	flag.StringVar(&o.fallbackGreeting, "fallback-greeting", "Colleague", "Used after \"Dear\" when an attendee's name would read badly (one word, all capitals, or an initial); empty to always use the name")
+	flag.StringVar(&o.greeting, "greeting", "first", "Address attendees in emails by first name (\"Dear John,\"), full name, or the name given with their roster email (\"John Smith <john@x.com>\", else first name): first, full, or email")
+	flag.StringVar(&o.certificateNote, "certificate-note", "", "Template for a line under the name on each certificate, e.g. '{{.Fields.chapter}} Chapter'")
+	flag.StringVar(&o.emailNote, "email-note", "", "Template for a paragraph in each email, e.g. 'As a member of the {{.Fields.chapter}} chapter...'")
+	flag.BoolVar(&o.force, "force", false, "Regenerate every certificate, even ones whose inputs haven't changed since the last run")
+	flag.BoolVar(&o.quiet, "quiet", false, "Don't print the timing summary at the end of the run")
+	flag.BoolVar(&o.assumeYes, "yes", false, "Answer yes to every prompt, for unattended runs")
+	flag.BoolVar(&o.failOnUnmatched, "fail-on-unmatched", false, "Abort instead of prompting when attendees have no valid email")
+	flag.StringVar(&o.rosterPath, "roster", "../PII/Roster.xlsx", "Roster file (.xlsx or .csv); pick a workbook sheet with file.xlsx#Sheet")
+	flag.StringVar(&o.rosterFormat, "roster-format", "auto", "How roster names are written: auto, first-last, or last-first (\"Smith, John\")")
+	flag.StringVar(&o.attendancePath, "attendance", "../PII/Attendance.xlsx", "Attendance file (.xlsx or .csv, or .txt with one name per line); pick a workbook sheet with file.xlsx#Sheet")
+	flag.Var(&o.calendarPaths, "calendar", "Calendar file (.xlsx or .csv); pick a workbook sheet with file.xlsx#Sheet; repeat to merge several calendars (default ../PII/Calendar.xlsx)")
+	flag.BoolVar(&o.listEvents, "list-events", false, "List all calendar events, mark the one that would be used, and exit")
+	flag.BoolVar(&o.diffRoster, "diff-roster", false, "Compare two rosters given as OLD NEW arguments, list added and removed members and changed emails, and exit")
+	flag.BoolVar(&o.checkOnly, "check", false, "Validate the roster, attendance, and calendar files and exit without generating or sending")
+	flag.StringVar(&o.onlyPath, "only", "", "File of names or emails (one per line); send only to these attendees")
+	flag.StringVar(&o.excludePath, "exclude", "", "File of names or emails (one per line); skip these attendees")
+	flag.Float64Var(&o.minPDH, "min-pdh", 0, "Only certify attendees who earned at least this many PDH, e.g. by sign-in/sign-out times (0 to certify everyone who earned any)")
+	flag.IntVar(&o.maxNameLength, "max-name-length", 60, "Flag attendee names longer than this many characters, 0 to allow any length")
+	flag.StringVar(&o.onBadName, "on-bad-name", "skip", "What to do with names over -max-name-length: skip (with a warning) or truncate")
+	flag.StringVar(&o.readOpts.Encoding, "encoding", "utf-8", "Character encoding of CSV input files: "+strings.Join(lib.CSVEncodings(), ", "))
+	flag.StringVar(&o.sortBy, "sort", "sheet", "Attendee processing order: sheet, first, or last (name)")
+	flag.BoolVar(&o.readOpts.Strict, "strict", false, "Fail on calendar rows missing a topic or speaker, unparseable dates, or no past events, instead of skipping them or falling back")
+	flag.BoolVar(&o.allowFuture, "allow-future", false, "Allow certificates for an event dated in the future")
+	flag.IntVar(&o.lookbackDays, "lookback-days", 0, "Only consider events from the last N days, so a stale calendar row can't be picked (0 for no limit)")
+	flag.StringVar(&o.eventTopic, "event-topic", "", "Topic of the event to use when several share the most recent date; with -event-date, the topic of an event given in full by flags")
+	flag.StringVar(&o.eventDate, "event-date", "", "Date of an off-calendar event, e.g. 2025-03-14; with -event-topic and -event-speaker, the calendar isn't read")
+	flag.StringVar(&o.eventSpeaker, "event-speaker", "", "Speaker of the -event-date event")
+	flag.StringVar(&o.eventLocation, "event-location", "", "Location of the -event-date event (optional)")
+	flag.StringVar(&o.eventTime, "event-time", "", "Time of the -event-date event (optional)")
+	flag.StringVar(&o.summaryTo, "summary-to", "", "Email a run summary (counts, failures, event details) to this address when done")
+	flag.BoolVar(&o.groupByEmail, "group-by-email", false, "Send attendees who share an email address one message with all their certificates")
+	flag.StringVar(&o.unmatchedTo, "unmatched-to", "", "Email the names of attendees with no valid roster email, or whose email bounced, to this address, so the organizer can correct the roster")
+	flag.StringVar(&o.zipTo, "zip-to", "", "Email all certificates as a single ZIP to this address instead of to each attendee")
+	flag.BoolVar(&pii.Emails, "redact", false, "Mask email addresses in console output (the -send-log keeps full detail)")
+	flag.BoolVar(&pii.Names, "redact-names", false, "Also mask attendee names in console output")
+	flag.StringVar(&o.sendLogPath, "send-log", "", "Append a CSV row with full detail for every attendee to this file")
+	flag.StringVar(&o.reportPath, "report", "", "Keep a JSON report of every attendee's outcome in this file, updated as the run goes")
+	flag.StringVar(&o.resumePath, "resume", "", "Continue the run recorded in this -report file, skipping attendees it shows as sent")
+	flag.StringVar(&o.exportAttendeesPath, "export-attendees", "", "Write the matched attendees (name, email, title, PDH, match type) to this CSV file; add -estimate to stop without sending")
+	flag.StringVar(&o.registryPath, "export-registry", "", "Merge each certificate's ID and details into this JSON registry file")
+	flag.StringVar(&o.credentialSource, "credential-source", "env", "Where to read Gmail credentials: env (../.env), file:PATH, or keyring")
+	flag.StringVar(&o.envName, "env", "", "Read credentials from ../.env.NAME, e.g. dev or prod; any env but prod writes to a maildir unless -live")
+	flag.BoolVar(&o.live, "live", false, "With a non-prod -env, send anyway (through SMTP or -sendmail) instead of writing to a maildir")
+	flag.StringVar(&o.surveyURL, "survey-url", "", "Feedback survey link added to each certificate email as \"Please share your feedback: URL\"")
+	flag.StringVar(&o.logoPath, "logo", "", "PNG or JPEG logo shown inline in an HTML version of each certificate email")
+	flag.Float64Var(&o.attachmentMaxMB, "attachment-max-mb", 10, "Largest total size in MB of the files attached to or embedded in one email, 0 for no limit")
+	flag.StringVar(&o.attachmentPolicy, "attachment-policy", "skip", "What to do with an email over -attachment-max-mb: skip (with a warning) or error (stop the run, recording everyone left as unsent)")
+	flag.StringVar(&o.numberPrefix, "number-prefix", "", "Print sequential certificate numbers with this prefix, e.g. LREC-2025- for LREC-2025-0001, counted in the -export-registry file")
+	flag.StringVar(&o.reissueID, "reissue", "", "Regenerate the certificate with this ID from the -export-registry file and exit")
+	flag.BoolVar(&o.byEvent, "by-event", false, "Save certificates in certificates/<event-date>-<topic>/ instead of temp_certificates")
+	flag.StringVar(&o.smtpHost, "smtp-host", "smtp.gmail.com", "SMTP server to send through")
+	flag.IntVar(&o.smtpPort, "smtp-port", 0, "SMTP port (default: 587 for starttls, 465 for ssl, 25 for none)")
+	flag.StringVar(&o.smtpTLS, "smtp-tls", "starttls", "SMTP encryption: starttls, ssl, or none")
+	flag.StringVar(&o.smtpCA, "smtp-ca", "", "PEM file of extra CA certificates to trust for the SMTP server, e.g. a private club CA")
+	flag.BoolVar(&o.smtpInsecure, "smtp-insecure", false, "Skip SMTP certificate verification (testing only: exposes credentials to impersonation)")
+	flag.StringVar(&o.maildir, "maildir", "", "Write each message as an .eml file in this directory instead of sending via SMTP")
+	flag.BoolVar(&o.noLock, "no-lock", false, "Don't take the per-event lockfile that stops a second run for the same event from sending at the same time")
+	flag.StringVar(&o.sendmailPath, "sendmail", "", "Pipe each message to this sendmail-compatible program (e.g. /usr/sbin/sendmail or msmtp) instead of sending via SMTP; only GMAIL_EMAIL is needed, as the From address")
+	flag.BoolVar(&o.estimate, "estimate", false, "Report the recipient count, estimated send time at -rate, and -daily-quota usage, and exit without generating or sending")
+	flag.IntVar(&o.dailyQuota, "daily-quota", 500, "Emails each account may send per day; warn when a run needs more (0 to skip the check)")
+	flag.DurationVar(&o.smtpTimeout, "smtp-timeout", 2*time.Minute, "Give up on a message the mail server hasn't accepted after this long, e.g. 90s (0 for no limit)")
+	flag.DurationVar(&o.deadline, "deadline", 0, "Stop sending once the whole run has taken this long, e.g. 30m, recording everyone left as unsent (0 for no limit)")
+	flag.IntVar(&o.rate, "rate", 0, "Maximum emails sent per minute, 0 for unlimited (20 is a safe value for Gmail)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTIONS]\n       %s -diff-roster [OPTIONS] OLD NEW\n\nOptions:\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), `
+Exit codes:
+  0  all certificates were generated and delivered
+  1  fatal setup error; nothing was sent, or -check found problems
+  2  the run completed but some attendees failed or were skipped
+`)
+	}
+
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	if len(o.calendarPaths) == 0 {
+		o.calendarPaths = pathList{"../PII/Calendar.xlsx"}
+	}
+
+	if o.smtpTimeout < 0 || o.deadline < 0 {
+		log.Fatalf("Invalid -smtp-timeout or -deadline: must not be negative")
+	}
+
+	switch o.orientation {
+	case "landscape":
+		o.certConfig.Orientation = "L"
+	case "portrait":
+		o.certConfig.Orientation = "P"
+	default:
+		log.Fatalf("Invalid -orientation %q: must be landscape or portrait", o.orientation)
+	}
+
+	if !containsString(certificateFormats, o.certificateFormat) {
+		log.Fatalf("Invalid -format %q: must be one of %s", o.certificateFormat, strings.Join(certificateFormats, ", "))
+	}
+
+	if o.sortBy != "sheet" && o.sortBy != "first" && o.sortBy != "last" {
+		log.Fatalf("Invalid -sort %q: must be sheet, first, or last", o.sortBy)
+	}
+
+	defaultPort, ok := smtpTLSModes[o.smtpTLS]
+	if !ok {
+		log.Fatalf("Invalid -smtp-tls %q: must be starttls, ssl, or none", o.smtpTLS)
+	}
+	if o.smtpPort == 0 {
+		o.smtpPort = defaultPort
+	}
+
+	if !containsString(lib.NameFormats(), o.rosterFormat) {
+		log.Fatalf("Invalid -roster-format %q: must be one of %s", o.rosterFormat, strings.Join(lib.NameFormats(), ", "))
+	}
+
+	if o.greeting != "first" && o.greeting != "full" && o.greeting != "email" {
+		log.Fatalf("Invalid -greeting %q: must be first, full, or email", o.greeting)
+	}
+
+	if err := lib.ValidateEncoding(o.readOpts.Encoding); err != nil {
+		log.Fatalf("Invalid -encoding: %v", err)
+	}
+	o.readOpts.PII = pii
+
+	if o.onBadName != "skip" && o.onBadName != "truncate" {
+		log.Fatalf("Invalid -on-bad-name %q: must be skip or truncate", o.onBadName)
+	}
+
+	if _, ok := lib.Designs[o.certConfig.Design]; !ok {
+		log.Fatalf("Unknown -design %q: must be one of %s", o.certConfig.Design, strings.Join(lib.DesignNames(), ", "))
+	}
+	if o.certificateFormat == "html" && !lib.HasHTMLLayout(o.certConfig.Design) {
+		log.Fatalf("-design %q has no HTML layout; use -format pdf", o.certConfig.Design)
+	}
+
+	if o.surveyURL != "" {
+		if u, err := url.Parse(o.surveyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Fatalf("Invalid -survey-url %q: must be an http:// or https:// URL", o.surveyURL)
+		}
+	}
+
+	if o.attachmentMaxMB < 0 {
+		log.Fatalf("Invalid -attachment-max-mb %v: must not be negative", o.attachmentMaxMB)
+	}
+
+	if o.attachmentPolicy != "skip" && o.attachmentPolicy != "error" {
+		log.Fatalf("Invalid -attachment-policy %q: must be skip or error", o.attachmentPolicy)
+	}
+
+	if o.dailyQuota < 0 {
+		log.Fatalf("Invalid -daily-quota %d: must not be negative", o.dailyQuota)
+	}
+
+	if o.lookbackDays < 0 {
+		log.Fatalf("Invalid -lookback-days %d: must not be negative", o.lookbackDays)
+	}
+
+	if o.minPDH < 0 {
+		log.Fatalf("Invalid -min-pdh %v: must not be negative", o.minPDH)
+	}
+
+	// An event given in full by flags replaces the calendar
+	if o.eventDate != "" {
+		event, err := manualEvent(o.eventTopic, o.eventSpeaker, o.eventDate, o.eventLocation, o.eventTime)
+		if err != nil {
+			log.Fatalf("Invalid event: %v", err)
+		}
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "calendar" || f.Name == "lookback-days" || f.Name == "list-events" {
+				log.Fatalf("-%s can't be used with -event-date, which doesn't read the calendar", f.Name)
+			}
+		})
+		o.flagEvent = &event
+	} else if o.eventSpeaker != "" || o.eventLocation != "" || o.eventTime != "" {
+		log.Fatalf("-event-speaker, -event-location, and -event-time need -event-date")
+	}
+
+	// Catch a missing or unsupported image now rather than on every certificate
+	if o.certConfig.SignaturePath != "" {
+		if _, err := lib.ImageType(o.certConfig.SignaturePath); err != nil {
+			log.Fatalf("Invalid -signature: %v", err)
+		}
+	}
+	if o.certConfig.LogoPath != "" {
+		if _, err := lib.ImageType(o.certConfig.LogoPath); err != nil {
+			log.Fatalf("Invalid -certificate-logo: %v", err)
+		}
+	}
+
+	if o.certConfig.LogoWidth <= 0 {
+		log.Fatalf("Invalid -logo-width %v: must be positive", o.certConfig.LogoWidth)
+	}
+
+	if o.certConfig.Background != "" {
+		if err := lib.ValidateBackground(o.certConfig.Background); err != nil {
+			log.Fatalf("Invalid -background: %v", err)
+		}
+	}
+	if err := lib.ValidateFontFamily(o.certConfig.FontFamily); err != nil {
+		log.Fatalf("Invalid -font-family: %v", err)
+	}
+	if o.textColor != "" {
+		color, err := lib.ParseColor(o.textColor)
+		if err != nil {
+			log.Fatalf("Invalid -text-color: %v", err)
+		}
+		o.certConfig.TextColor = &color
+	}
+
+	if !containsString(lib.Languages(), o.certConfig.Lang) {
+		log.Fatalf("Unknown -lang %q: must be one of %s", o.certConfig.Lang, strings.Join(lib.Languages(), ", "))
+	}
+	// Each language has its own date order unless -date-format says otherwise
+	dateFormatSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "date-format" {
+			dateFormatSet = true
+		}
+	})
+	if !dateFormatSet {
+		o.dateFormat = lib.DateLayout(o.certConfig.Lang)
+	}
+
+	// Named environments keep test credentials apart from the club's; outside
+	// prod nothing reaches real members by default
+	if o.envName != "" {
+		if !validEnvName(o.envName) {
+			log.Fatalf("Invalid -env %q: must be letters, digits, - or _", o.envName)
+		}
+		credentialSourceSet := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "credential-source" {
+				credentialSourceSet = true
+			}
+		})
+		if credentialSourceSet {
+			log.Fatalf("-env and -credential-source can't be used together")
+		}
+		o.credentialSource = "env:" + o.envName
+		if o.envName != "prod" && o.maildir == "" && !o.live {
+			if o.sendmailPath != "" {
+				log.Fatalf("-env %s is not prod and writes to a maildir, so it can't be used with -sendmail; pass -live to send through it", o.envName)
+			}
+			o.maildir = "outbox-" + o.envName
+			fmt.Printf("Environment %s is not prod: writing messages to %s/ instead of sending (pass -live to send)\n", o.envName, o.maildir)
+		}
+	}
+
+	if o.sendmailPath != "" {
+		if o.maildir != "" {
+			log.Fatalf("-sendmail and -maildir can't be used together")
+		}
+		if _, err := exec.LookPath(o.sendmailPath); err != nil {
+			log.Fatalf("Invalid -sendmail: %v", err)
+		}
+	}
+
+	return o
+}
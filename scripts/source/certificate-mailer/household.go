@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// emailKey normalizes an address so household members match regardless of
+// case or stray spaces.
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// sharedEmails counts attendees per normalized email, so -group-by-email can
+// tell which certificates to hold back for a combined message.
+func sharedEmails(attendees []lib.Attendee) map[string]int {
+	counts := make(map[string]int)
+	for _, attendee := range attendees {
+		counts[emailKey(attendee.Email)]++
+	}
+	return counts
+}
+
+// joinNames lists names for a greeting: "John", "John and Jane", or
+// "John, Jane, and Sam".
+func joinNames(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", and " + names[len(names)-1]
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+	"gopkg.in/gomail.v2"
+)
+
+// certificateEmailHTML mirrors the plain-text certificate email, with the
+// club logo shown inline above the greeting.
+var certificateEmailHTML = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Georgia, serif; color: #222;">
+<p><img src="cid:{{.CID}}" alt="Little Rock Engineers Club" style="max-width: 240px;"></p>
+<p>Dear {{.Name}},</p>
+<p>Please find attached your {{.Certificates}} for the Little Rock Engineers Club presentation:</p>
+<p>Speaker: {{.Speaker}}<br>
+Topic: {{.Topic}}<br>
+Date: {{.Date}}</p>
+{{if .Note}}<p>{{.Note}}</p>
+{{end}}<p>Thank you for attending this presentation.</p>
+{{if .Survey}}<p>Please share your feedback: <a href="{{.Survey}}">{{.Survey}}</a></p>
+{{end}}<p>Best regards,<br>
+Little Rock Engineers Club</p>
+</body>
+</html>
+`))
+
+// addLogoAlternative adds an HTML version of the certificate email with the
+// logo embedded by Content-ID, so mail clients show it inline rather than as
+// an attachment. The plain-text body stays first for clients without HTML.
+func addLogoAlternative(m *gomail.Message, config EmailConfig, event lib.EventInfo, greeting, certificates, note string) error {
+	// The extension is kept so the part gets the right image content type
+	cid := "logo" + strings.ToLower(filepath.Ext(config.Logo))
+
+	var html strings.Builder
+	err := certificateEmailHTML.Execute(&html, map[string]string{
+		"CID":          cid,
+		"Name":         greeting,
+		"Certificates": certificates,
+		"Speaker":      event.Speaker,
+		"Topic":        event.Topic,
+		"Date":         event.DisplayDate,
+		"Note":         note,
+		"Survey":       config.SurveyURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render HTML body: %v", err)
+	}
+
+	m.AddAlternative("text/html", html.String())
+	m.Embed(config.Logo, gomail.Rename(cid))
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// runLock is held while a run sends an event's certificates, so a second
+// run for the same event (a cron overlap, a double launch) refuses to
+// start instead of emailing everyone again.
+type runLock struct {
+	path string
+}
+
+// eventLockPath keys the lockfile by event, so runs for different events
+// or chapters' calendars don't block each other.
+func eventLockPath(event lib.EventInfo) string {
+	return filepath.Join(os.TempDir(), "certificate-mailer-"+filepath.Base(eventOutputDir(event))+".lock")
+}
+
+// acquireRunLock creates the lockfile with this process's ID in it. A lock
+// left by a run that is no longer alive, e.g. one that crashed or exited
+// through log.Fatalf, is taken over; one held by a live process is an
+// error naming it.
+func acquireRunLock(path string) (*runLock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return &runLock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		pid, running := lockHolder(path)
+		if running {
+			holder := "another run"
+			if pid > 0 {
+				holder = fmt.Sprintf("another run (process %d)", pid)
+			}
+			return nil, fmt.Errorf("%s is already sending this event and holds %s; pass -no-lock if that is intended", holder, path)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing stale lock %s: %v", path, err)
+		}
+	}
+	return nil, fmt.Errorf("could not acquire %s", path)
+}
+
+// lockHolder reads the process ID from a lockfile and reports whether that
+// process is still running. When liveness can't be determined, such as a
+// process owned by another user, the lock is treated as held.
+func lockHolder(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Released between our create and this read
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		// Empty or partly written: the holder may be starting right now
+		return 0, true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return pid, false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return pid, !errors.Is(err, os.ErrProcessDone)
+}
+
+// Release removes the lockfile. It is safe to call on a nil lock and more
+// than once.
+func (l *runLock) Release() {
+	if l == nil || l.path == "" {
+		return
+	}
+	os.Remove(l.path)
+	l.path = ""
+}
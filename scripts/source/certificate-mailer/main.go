@@ -1,498 +1,886 @@
 package main
 
 import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"text/tabwriter"
+	"text/template"
 	"time"
 
-	"github.com/joho/godotenv"
-	"github.com/jung-kurt/gofpdf"
-	"github.com/xuri/excelize/v2"
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
 	"gopkg.in/gomail.v2"
 )
 
-type EventInfo struct {
-	Date     string
-	Topic    string
-	Speaker  string
-	Location string
-	Time     string
+type EmailConfig struct {
+	SMTPHost string
+	SMTPPort int
+	// SMTPS connects with TLS from the start instead of STARTTLS
+	SMTPS       bool
+	TLS         *tls.Config
+	Email       string
+	AppPassword string
+	Subject     *template.Template
+	// OAuth, when set, authenticates with XOAUTH2 instead of AppPassword
+	OAuth *oauthCredentials
+	// Logo, when set, is embedded inline in an HTML version of each
+	// certificate email
+	Logo string
+	// Maildir, when set, receives each message as an .eml file instead of
+	// sending it over SMTP
+	Maildir string
+	// Sendmail, when set, is a sendmail-compatible program each message is
+	// piped to instead of sending it over SMTP
+	Sendmail string
+	// Note, when set, adds a personalized paragraph to each email body
+	Note *template.Template
+	// Greeting is "first" to open emails with "Dear John," "full" for
+	// "Dear John Smith," or "email" for the roster's display name, if any
+	Greeting string
+	// FallbackGreeting, when set, replaces a name that uncertainGreeting
+	// flags, as in "Dear Colleague,"
+	FallbackGreeting string
+	// SurveyURL, when set, is linked as a feedback request in each email
+	SurveyURL string
+	// AttachmentMax caps the bytes attached to or embedded in one message,
+	// 0 for no limit
+	AttachmentMax int64
+	// Timeout bounds sending one message, 0 for no limit
+	Timeout time.Duration
 }
 
-type Attendee struct {
-	Name  string
-	Email string
+// greetingName is how an attendee is addressed after "Dear".
+func (c EmailConfig) greetingName(attendee lib.Attendee) string {
+	name, _ := c.greeting(attendee)
+	return name
 }
 
-type EmailConfig struct {
-	SMTPHost    string
-	SMTPPort    int
-	Email       string
-	AppPassword string
+// greeting returns greetingName and whether it is FallbackGreeting standing
+// in for a name that would read badly.
+func (c EmailConfig) greeting(attendee lib.Attendee) (string, bool) {
+	if c.Greeting == "email" && attendee.EmailName != "" {
+		return attendee.EmailName, false
+	}
+	name := attendee.FirstName()
+	if c.Greeting == "full" {
+		name = attendee.Name
+	}
+	if c.FallbackGreeting != "" && uncertainGreeting(name, attendee.Name) {
+		return c.FallbackGreeting, true
+	}
+	return name, false
 }
 
-func main() {
-	// Load environment variables
+type SubjectData struct {
+	Name    string
+	Date    string
+	Topic   string
+	Speaker string
+	Fields  map[string]string
+}
 
-	// dir, _ := os.Getwd()
-	// fmt.Println("Current working directory:", dir)
-	err := godotenv.Load("../.env")
+// pii masks attendee details in console output; see lib.Redactor. main sets
+// it from -redact and -redact-names and hands it to lib in readOpts.
+var pii lib.Redactor
+
+const defaultSubject = "LREC Certificate of Attendance - {{.Name}} - {{.Date}}"
+
+// parseNote parses an optional per-attendee note template. Roster fields an
+// attendee lacks render as empty rather than "<no value>".
+func parseNote(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(name).Option("missingkey=zero").Parse(text)
+}
+
+// renderNote executes a note template against the attendee, so it can use
+// .Name, .FirstName, .Title, and roster columns as .Fields.<column>. A nil
+// template renders as "".
+func renderNote(tmpl *template.Template, attendee lib.Attendee) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	var note strings.Builder
+	if err := tmpl.Execute(&note, attendee); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(note.String()), nil
+}
+
+// checkSubject renders the -subject once for a sample attendee, the first
+// with roster columns, with missing fields an error. A typo such as
+// {{.Fields.chaptr}} then stops the run before anything is generated or sent,
+// rather than putting "<no value>" in every subject line.
+func checkSubject(tmpl *template.Template, attendees []lib.Attendee, event lib.EventInfo) error {
+	sample := lib.Attendee{Name: "Jane Doe", Fields: map[string]string{}}
+	for _, attendee := range attendees {
+		if attendee.Fields != nil {
+			sample = attendee
+			break
+		}
+	}
+	check, err := tmpl.Clone()
 	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		return err
+	}
+	return check.Option("missingkey=error").Execute(io.Discard, SubjectData{
+		Name:    sample.Name,
+		Date:    event.DisplayDate,
+		Topic:   event.Topic,
+		Speaker: event.Speaker,
+		Fields:  sample.Fields,
+	})
+}
+
+func main() {
+	timer := newRunTimer()
+	o := parseFlags()
+
+	// Every send takes ctx, so once the -deadline passes the rest of the run
+	// is skipped rather than left hanging
+	ctx := context.Background()
+	if o.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, o.deadline, fmt.Errorf("-deadline of %s reached", o.deadline))
+		defer cancel()
 	}
 
-	// Setup email configuration
-	emailConfig := EmailConfig{
-		SMTPHost:    "smtp.gmail.com",
-		SMTPPort:    587,
-		Email:       os.Getenv("GMAIL_EMAIL"),
-		AppPassword: os.Getenv("GMAIL_APP_PASSWORD"),
+	if o.listEvents {
+		if err := printEventList(o.calendarPaths, o.eventTopic, o.lookbackDays, o.readOpts); err != nil {
+			log.Fatalf("Error reading calendar: %s", describeReadError(err))
+		}
+		return
 	}
 
-	if emailConfig.Email == "" || emailConfig.AppPassword == "" {
-		log.Fatalf("Gmail credentials not found in .env file. Please set GMAIL_EMAIL and GMAIL_APP_PASSWORD")
+	if o.diffRoster {
+		if flag.NArg() != 2 {
+			log.Fatalf("-diff-roster needs two roster files: OLD NEW")
+		}
+		if err := diffRosters(flag.Arg(0), flag.Arg(1), lib.NameFormat(o.rosterFormat), o.readOpts); err != nil {
+			log.Fatalf("Error reading roster: %s", describeReadError(err))
+		}
+		return
 	}
 
-	// Read roster to get email mappings
-	roster, err := readRoster("../PII/Roster.xlsx")
-	if err != nil {
-		log.Fatalf("Error reading roster: %v", err)
+	if o.checkOnly {
+		if !runCheck(o.rosterPath, lib.NameFormat(o.rosterFormat), o.attendancePath, o.calendarPaths, o.credentialSource, o.readOpts) {
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Read attendance data
-	attendees, err := readAttendance("../PII/Attendance.xlsx")
-	if err != nil {
-		log.Fatalf("Error reading attendance: %v", err)
+	if o.numberPrefix != "" && o.registryPath == "" {
+		log.Fatalf("-number-prefix needs -export-registry to keep count of the numbers issued")
 	}
 
-	// Match attendees with email addresses from roster
-	attendees = matchAttendeesWithEmails(attendees, roster)
+	if o.reissueID != "" {
+		if o.registryPath == "" {
+			log.Fatalf("-reissue needs -export-registry to locate the registry")
+		}
+		os.MkdirAll("temp_certificates", 0755)
+		path, err := reissueCertificate(o.registryPath, o.reissueID, o.certConfig, o.dateFormat, o.certificateFormat, "temp_certificates")
+		if err != nil {
+			log.Fatalf("Error reissuing certificate: %v", err)
+		}
+		fmt.Printf("Reissued %s as %s\n", o.reissueID, path)
+		return
+	}
 
-	// Read calendar data and get most recent event
-	event, err := getMostRecentEvent("../PII/Calendar.xlsx")
+	certificateNoteTmpl, err := parseNote("certificate-note", o.certificateNote)
 	if err != nil {
-		log.Fatalf("Error reading calendar: %v", err)
+		log.Fatalf("Error parsing -certificate-note: %v", err)
 	}
+	emailConfig, senders := newEmailConfig(o)
 
-	// Create temp directory for PDFs
-	tempDir := "temp_certificates"
-	os.MkdirAll(tempDir, 0755)
+	attendees, unmatched, failures, totalAttendees := resolveAttendees(o, emailConfig)
+	event := resolveEvent(o)
+	// The ZIP email has its own subject, so -subject only matters otherwise
+	if o.zipTo == "" {
+		if err := checkSubject(emailConfig.Subject, attendees, event); err != nil {
+			log.Fatalf("Invalid -subject: %v", err)
+		}
+	}
 
-	// Generate certificates and send individual emails
-	sentCount := 0
-	for _, attendee := range attendees {
-		filePath, err := generateCertificate(attendee, event, tempDir)
+	// Pick up from an earlier run's report: anyone it shows as sent is done,
+	// and everyone else (failed or never reached) goes through again
+	var prior *runReport
+	if o.resumePath != "" {
+		prior, attendees, failures = resumeRun(o.resumePath, event, attendees, failures)
+		if o.reportPath == "" {
+			o.reportPath = o.resumePath
+		}
+	}
+
+	var report *runReport
+	if o.reportPath != "" {
+		report = newReport(o.reportPath, event, prior)
+		for _, failure := range failures {
+			report.Record(failure.Attendee, event, "", failure.Step+"-failed", failure.Err)
+		}
+	}
+
+	emails := len(attendees)
+	if o.groupByEmail {
+		emails = len(sharedEmails(attendees))
+	}
+	if o.zipTo != "" {
+		emails = 1
+	}
+
+	if o.estimate {
+		fmt.Printf("\nEvent: %s (%s)\n", event.Topic, event.DisplayDate)
+		printEstimate(len(attendees), emails, len(senders), o.rate, o.dailyQuota)
+		return
+	}
+	if warning := quotaWarning(emails, len(senders), o.dailyQuota); warning != "" && o.maildir == "" {
+		log.Printf("Warning: %s", warning)
+	}
+
+	// Keep a second run for this event from sending alongside this one. A
+	// maildir run sends nothing, so it neither takes nor waits for the lock.
+	var lock *runLock
+	if o.maildir == "" && !o.noLock {
+		lock, err = acquireRunLock(eventLockPath(event))
 		if err != nil {
-			log.Printf("Error generating certificate for %s: %v", attendee.Name, err)
-			continue
+			log.Fatalf("Error %v", err)
+		}
+		defer lock.Release()
+	}
+
+	// Last chance to back out before anything is generated or sent. Writing to
+	// a maildir sends nothing, so it doesn't ask.
+	if o.maildir == "" {
+		fmt.Printf("\nEvent: %s (%s)\n", event.Topic, event.DisplayDate)
+		// A ZIP goes to the organizer, so attendee emails don't count
+		var question string
+		if o.zipTo != "" {
+			fmt.Printf("Attendees: %d, certificates: %d\n", totalAttendees, len(attendees))
+			question = fmt.Sprintf("Send %d certificates to %s in one email?", len(attendees), pii.Email(o.zipTo))
+		} else {
+			fmt.Printf("Attendees: %d, with valid emails: %d\n", totalAttendees, len(attendees))
+			question = fmt.Sprintf("Send %d emails?", emails)
+		}
+		if !o.assumeYes && !confirm(question) {
+			log.Fatalf("Aborted by user")
 		}
-		fmt.Printf("Generated certificate for %s\n", attendee.Name)
+	}
 
-		err = sendIndividualCertificateEmail(emailConfig, event, attendee, filePath)
+	var results *sendLog
+	if o.sendLogPath != "" {
+		results, err = openSendLog(o.sendLogPath)
 		if err != nil {
-			log.Printf("Error sending email to %s: %v", attendee.Name, err)
+			log.Fatalf("Error opening send log: %v", err)
+		}
+		defer results.Close()
+	}
+
+	// Create temp directory for PDFs
+	tempDir := "temp_certificates"
+	if o.byEvent {
+		tempDir = eventOutputDir(event)
+	}
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		log.Fatalf("Error creating output directory: %v", err)
+	}
+
+	// Pace sends so large events don't trip Gmail's burst limits
+	var throttle <-chan time.Time
+	if o.rate > 0 {
+		ticker := time.NewTicker(time.Minute / time.Duration(o.rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	// The certificates are sent under their own context, so stopping them
+	// early (see -attachment-policy) still leaves the summary to go out
+	deliveryCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	d := &delivery{
+		ctx:             deliveryCtx,
+		stop:            stop,
+		timer:           timer,
+		opts:            o,
+		event:           event,
+		emailConfig:     emailConfig,
+		senders:         senders,
+		certificateNote: certificateNoteTmpl,
+		results:         results,
+		report:          report,
+		throttle:        throttle,
+		outputDir:       tempDir,
+		failures:        failures,
+	}
+	d.run(attendees)
+
+	fmt.Printf("\nSuccessfully generated %d certificates and sent %d emails\n", d.generated, d.sent)
+	if d.unsent > 0 {
+		fmt.Printf("%d attendee(s) left unsent: %v\n", d.unsent, context.Cause(deliveryCtx))
+	}
+
+	if o.summaryTo != "" {
+		summary := runSummary{
+			Attendees: totalAttendees,
+			Generated: d.generated,
+			Sent:      d.sent,
+			ZipTo:     o.zipTo,
+			Failures:  d.failures,
+		}
+		if err := sendSummaryEmail(ctx, emailConfig, event, o.summaryTo, summary); err != nil {
+			log.Printf("Error sending summary to %s: %s", pii.Email(o.summaryTo), pii.Scrub(err.Error(), lib.Attendee{Email: o.summaryTo}))
+			d.failures = append(d.failures, runFailure{Attendee: lib.Attendee{Email: o.summaryTo}, Step: "summary", Err: err})
 		} else {
-			sentCount++
-			fmt.Printf("Email sent to %s (%s)\n", attendee.Name, attendee.Email)
+			fmt.Printf("Summary sent to %s\n", pii.Email(o.summaryTo))
 		}
+	}
 
+	if o.unmatchedTo != "" && (len(unmatched) > 0 || len(d.bounced) > 0) && o.zipTo == "" {
+		if err := sendUnmatchedEmail(ctx, emailConfig, event, o.unmatchedTo, unmatched, d.bounced); err != nil {
+			log.Printf("Error sending unmatched list to %s: %s", pii.Email(o.unmatchedTo), pii.Scrub(err.Error(), lib.Attendee{Email: o.unmatchedTo}))
+			d.failures = append(d.failures, runFailure{Attendee: lib.Attendee{Email: o.unmatchedTo}, Step: "unmatched", Err: err})
+		} else {
+			fmt.Printf("Sent %d unmatched and %d bounced name(s) to %s\n", len(unmatched), len(d.bounced), pii.Email(o.unmatchedTo))
+		}
 	}
 
-	fmt.Printf("\nSuccessfully generated %d certificates and sent %d emails\n", len(attendees), sentCount)
+	if !o.quiet {
+		timer.print()
+	}
+
+	// Partial failure: deferred cleanup doesn't run on os.Exit, so close the
+	// send log and release the lock explicitly first
+	if len(d.failures) > 0 {
+		results.Close()
+		lock.Release()
+		os.Exit(2)
+	}
 }
 
-func readAttendance(filepath string) ([]Attendee, error) {
-	f, err := excelize.OpenFile(filepath)
+// newEmailConfig parses the message templates and loads the credentials for
+// the run, returning the primary account's settings and every account to
+// rotate certificate emails through.
+func newEmailConfig(o options) (EmailConfig, []EmailConfig) {
+	// Parse the subject template up front so a bad template fails before sending
+	subjectTmpl, err := template.New("subject").Parse(o.subject)
+	if err != nil {
+		log.Fatalf("Error parsing subject template: %v", err)
+	}
+	emailNoteTmpl, err := parseNote("email-note", o.emailNote)
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error parsing -email-note: %v", err)
 	}
-	defer f.Close()
 
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("no sheets found in attendance file")
+	// Load environment variables
+
+	// dir, _ := os.Getwd()
+	// fmt.Println("Current working directory:", dir)
+	// Credentials aren't needed when writing to a maildir, or for -estimate
+	err = loadCredentials(o.credentialSource)
+	if err != nil && o.maildir == "" && o.sendmailPath == "" && !o.estimate {
+		log.Fatalf("Error loading credentials: %v", err)
 	}
 
-	rows, err := f.GetRows(sheets[0])
+	smtpTLSConf, err := smtpTLSConfig(o.smtpHost, o.smtpCA, o.smtpInsecure)
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error reading -smtp-ca: %v", err)
 	}
 
-	var attendees []Attendee
-	nameCol := -1
-
-	// Find Name column
-	if len(rows) > 0 {
-		for i, cell := range rows[0] {
-			if strings.Contains(strings.ToLower(cell), "name") {
-				nameCol = i
-				break
-			}
+	// Setup email configuration
+	emailConfig := EmailConfig{
+		SMTPHost:    o.smtpHost,
+		SMTPPort:    o.smtpPort,
+		SMTPS:       o.smtpTLS == "ssl",
+		TLS:         smtpTLSConf,
+		Email:       os.Getenv("GMAIL_EMAIL"),
+		AppPassword: os.Getenv("GMAIL_APP_PASSWORD"),
+		Subject:     subjectTmpl,
+		OAuth:       oauthFromEnv(""),
+		Logo:        o.logoPath,
+		Maildir:     o.maildir,
+		Sendmail:    o.sendmailPath,
+		Note:        emailNoteTmpl,
+		Greeting:    o.greeting,
+		SurveyURL:   o.surveyURL,
+
+		FallbackGreeting: o.fallbackGreeting,
+		AttachmentMax:    int64(o.attachmentMaxMB * (1 << 20)),
+		Timeout:          o.smtpTimeout,
+	}
+
+	if o.logoPath != "" {
+		if _, err := os.Stat(o.logoPath); err != nil {
+			log.Fatalf("Error reading logo: %v", err)
+		}
+		// The logo goes into every message, so on its own it must fit
+		if err := checkAttachmentSize([]string{o.logoPath}, emailConfig.AttachmentMax); err != nil {
+			log.Fatalf("Invalid -logo: %v", err)
 		}
 	}
 
-	if nameCol == -1 {
-		return nil, fmt.Errorf("Name column not found")
+	if o.maildir != "" {
+		if err := os.MkdirAll(o.maildir, 0755); err != nil {
+			log.Fatalf("Error creating maildir: %v", err)
+		}
+		if emailConfig.Email == "" {
+			emailConfig.Email = "certificates@localhost"
+		}
+	} else if o.sendmailPath != "" {
+		if emailConfig.Email == "" {
+			log.Fatalf("-sendmail needs GMAIL_EMAIL set as the From address")
+		}
+	} else if emailConfig.Email == "" || (emailConfig.AppPassword == "" && emailConfig.OAuth == nil) {
+		log.Fatalf("Gmail credentials not found. Please set GMAIL_EMAIL and either GMAIL_APP_PASSWORD or the GMAIL_OAUTH_* variables")
 	}
 
-	// Read attendee names (skip header row)
-	for i := 1; i < len(rows); i++ {
-		if len(rows[i]) > nameCol && rows[i][nameCol] != "" {
-			name := convertNameFormat(rows[i][nameCol])
-			fmt.Printf("Roster name = %s \n", name)
-			attendees = append(attendees, Attendee{Name: name, Email: ""})
+	// Certificate emails rotate through every configured account
+	senders := senderConfigs(emailConfig)
+	for _, sender := range senders[1:] {
+		if o.maildir == "" && o.sendmailPath == "" && !o.estimate && sender.AppPassword == "" && sender.OAuth == nil {
+			log.Fatalf("No app password or OAuth credentials for sending account %s", sender.Email)
 		}
 	}
+	if len(senders) > 1 {
+		fmt.Printf("Sending from %d accounts in rotation\n", len(senders))
+	}
 
-	return attendees, nil
+	return emailConfig, senders
 }
 
-func convertNameFormat(name string) string {
-	// Convert from "Last, First" to "First Last"
-	parts := strings.Split(name, ",")
-	if len(parts) == 2 {
-		first := strings.TrimSpace(parts[1])
-		last := strings.TrimSpace(parts[0])
-		return first + " " + last
+// resolveAttendees reads the roster and attendance, matches attendees to
+// roster emails, and narrows them to the ones this run certifies. It returns
+// those attendees, the ones with no valid email, the failures recorded so
+// far, and the attendance count before any were skipped.
+func resolveAttendees(o options, emailConfig EmailConfig) ([]lib.Attendee, []lib.Attendee, []runFailure, int) {
+	// Read roster to get email mappings
+	roster, err := lib.ReadRoster(o.rosterPath, lib.NameFormat(o.rosterFormat), o.readOpts)
+	if err != nil {
+		log.Fatalf("Error reading roster: %s", describeReadError(err))
 	}
-	return strings.TrimSpace(name)
-}
 
-func getMostRecentEvent(filepath string) (EventInfo, error) {
-	f, err := excelize.OpenFile(filepath)
+	// Read attendance data
+	attendees, err := lib.ReadAttendance(o.attendancePath, o.readOpts)
 	if err != nil {
-		return EventInfo{}, err
+		log.Fatalf("Error reading attendance: %s", describeReadError(err))
+	}
+	for _, attendee := range attendees {
+		fmt.Printf("Roster name = %s \n", pii.Name(attendee.Name))
 	}
-	defer f.Close()
 
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return EventInfo{}, fmt.Errorf("no sheets found in calendar file")
+	// Match attendees with email addresses from roster
+	attendees = lib.MatchAttendeesWithEmails(attendees, roster)
+
+	if o.exportAttendeesPath != "" {
+		if err := exportAttendees(o.exportAttendeesPath, attendees); err != nil {
+			log.Fatalf("Error writing -export-attendees: %v", err)
+		}
+		fmt.Printf("Exported %d attendees to %s\n", len(attendees), o.exportAttendeesPath)
 	}
 
-	rows, err := f.GetRows(sheets[0])
-	if err != nil {
-		return EventInfo{}, err
-	}
-
-	// Find column indices - check first two rows for headers
-	dateCol, topicCol, speakerCol, locationCol, timeCol := -1, -1, -1, -1, -1
-	headerRow := 0
-
-	for rowIdx := 0; rowIdx < 2 && rowIdx < len(rows); rowIdx++ {
-		for i, cell := range rows[rowIdx] {
-			cellLower := strings.ToLower(cell)
-			if strings.Contains(cellLower, "date") {
-				dateCol = i
-				headerRow = rowIdx
-			} else if strings.Contains(cellLower, "topic") {
-				topicCol = i
-			} else if strings.Contains(cellLower, "speaker") {
-				speakerCol = i
-			} else if strings.Contains(cellLower, "location") {
-				locationCol = i
-			} else if strings.Contains(cellLower, "time") {
-				timeCol = i
-			}
+	// Narrow the run to a subset of attendees if requested
+	if o.onlyPath != "" {
+		list, err := readAttendeeList(o.onlyPath)
+		if err != nil {
+			log.Fatalf("Error reading -only list: %v", err)
 		}
-		if dateCol != -1 && topicCol != -1 && speakerCol != -1 {
-			break
+		attendees = filterAttendees(attendees, list, true)
+	}
+	if o.excludePath != "" {
+		list, err := readAttendeeList(o.excludePath)
+		if err != nil {
+			log.Fatalf("Error reading -exclude list: %v", err)
 		}
+		attendees = filterAttendees(attendees, list, false)
 	}
-
-	if dateCol == -1 || topicCol == -1 || speakerCol == -1 {
-		return EventInfo{}, fmt.Errorf("required columns not found")
+	attendees = dropZeroPDH(attendees)
+	if o.minPDH > 0 {
+		attendees = filterByPDH(attendees, o.minPDH)
 	}
 
-	// Find the most recent non-empty event
-	var events []EventInfo
-	for i := headerRow + 1; i < len(rows); i++ {
-		if len(rows[i]) > dateCol && rows[i][dateCol] != "" {
-			event := EventInfo{}
-			event.Date = rows[i][dateCol]
+	lib.SortAttendees(attendees, o.sortBy)
 
-			if len(rows[i]) > topicCol {
-				event.Topic = rows[i][topicCol]
-			}
-			if len(rows[i]) > speakerCol {
-				event.Speaker = rows[i][speakerCol]
-			}
-			if locationCol != -1 && len(rows[i]) > locationCol {
-				event.Location = rows[i][locationCol]
-			}
-			if timeCol != -1 && len(rows[i]) > timeCol {
-				event.Time = rows[i][timeCol]
-			}
+	totalAttendees := len(attendees)
+	var failures []runFailure
+
+	attendees, skipped := checkNameLengths(attendees, o.maxNameLength, o.onBadName)
+	for _, attendee := range skipped {
+		failures = append(failures, runFailure{Attendee: attendee, Step: "validate", Err: fmt.Errorf("name longer than %d characters", o.maxNameLength)})
+	}
 
-			if event.Topic != "" && event.Speaker != "" {
-				events = append(events, event)
+	// Point out greetings that fell back so the names can be fixed at the source
+	if o.zipTo == "" {
+		for _, attendee := range attendees {
+			if name, fallback := emailConfig.greeting(attendee); fallback {
+				log.Printf("Warning: can't tell a first name from %s; greeting them as \"Dear %s,\"", pii.Name(attendee.Name), name)
 			}
 		}
 	}
 
-	if len(events) == 0 {
-		return EventInfo{}, fmt.Errorf("no valid events found")
+	// Pre-flight: surface every undeliverable attendee before any work is done.
+	// In ZIP mode the organizer distributes certificates, so emails don't matter.
+	unmatched := lib.FindUnmatchedAttendees(attendees)
+	if len(unmatched) > 0 && o.zipTo == "" {
+		fmt.Printf("\n%d attendee(s) have no valid email address:\n", len(unmatched))
+		for _, attendee := range unmatched {
+			fmt.Printf("  - %s\n", pii.Name(attendee.Name))
+		}
+		if o.failOnUnmatched {
+			log.Fatalf("Aborting: %d attendee(s) could not be matched to an email address", len(unmatched))
+		}
+		if !o.assumeYes && !confirm("Skip them and continue?") {
+			log.Fatalf("Aborted by user")
+		}
+		attendees = lib.WithoutAttendees(attendees, unmatched)
+		for _, attendee := range unmatched {
+			failures = append(failures, runFailure{Attendee: attendee, Step: "match", Err: fmt.Errorf("no valid email address")})
+		}
 	}
 
-	// Filter events to only include past events and sort by date to get most recent past event
-	now := time.Now()
-	var pastEvents []EventInfo
+	return attendees, unmatched, failures, totalAttendees
+}
 
-	for _, event := range events {
-		eventDate, err := parseFlexibleDate(event.Date)
-		if err == nil && eventDate.Before(now) {
-			pastEvents = append(pastEvents, event)
+// resumeRun reads the -resume report of an earlier run for event and drops
+// the attendees and failures it shows as sent.
+func resumeRun(path string, event lib.EventInfo, attendees []lib.Attendee, failures []runFailure) (*runReport, []lib.Attendee, []runFailure) {
+	prior, err := readReport(path)
+	if err != nil {
+		log.Fatalf("Error reading -resume report: %v", err)
+	}
+	if prior.EventDate != event.Date || prior.EventTopic != event.Topic {
+		log.Fatalf("-resume report %s is for %q on %s, not %q on %s", path, prior.EventTopic, prior.EventDate, event.Topic, event.Date)
+	}
+	var done []lib.Attendee
+	for _, attendee := range attendees {
+		if prior.sent(attendee, event) {
+			done = append(done, attendee)
+		}
+	}
+	attendees = lib.WithoutAttendees(attendees, done)
+	var remaining []runFailure
+	for _, failure := range failures {
+		if !prior.sent(failure.Attendee, event) {
+			remaining = append(remaining, failure)
 		}
 	}
+	fmt.Printf("Resuming from %s: %d attendee(s) already sent, %d to go\n", path, len(done), len(attendees))
+	return prior, attendees, remaining
+}
+
+// resolveEvent returns the event being certified: the one given by
+// -event-date, or else the most recent in the calendar.
+func resolveEvent(o options) lib.EventInfo {
+	var event lib.EventInfo
+	if o.flagEvent != nil {
+		event = *o.flagEvent
+	} else {
+		var err error
+		event, err = lib.MostRecentEvent(o.calendarPaths, o.eventTopic, o.lookbackDays, o.readOpts)
+		if err != nil {
+			log.Fatalf("Error reading calendar: %s", describeReadError(err))
+		}
+	}
+	event.DisplayDate = lib.FormatEventDate(event.Date, o.dateFormat, o.certConfig.Lang)
+
+	// With no past events MostRecentEvent falls back to the whole calendar,
+	// which would certify attendance at a meeting that hasn't happened
+	if eventDate, err := lib.ParseFlexibleDate(event.Date); err == nil && eventDate.After(time.Now()) && !o.allowFuture {
+		log.Fatalf("Event %q on %s is in the future; pass -allow-future to issue certificates anyway", event.Topic, event.Date)
+	}
+	return event
+}
 
-	// If no past events, use all events (fallback)
-	if len(pastEvents) == 0 {
-		pastEvents = events
+// printEventList prints every calendar event in date order, marking the one
+// lib.MostRecentEvent would select.
+func printEventList(calendarPaths []string, eventTopic string, lookbackDays int, opts lib.ReadOptions) error {
+	events, err := lib.ReadCalendars(calendarPaths, opts)
+	if err != nil {
+		return err
 	}
 
-	// Sort past events by date to get most recent
-	sort.Slice(pastEvents, func(i, j int) bool {
-		// Try to parse dates
-		date1, err1 := parseFlexibleDate(pastEvents[i].Date)
-		date2, err2 := parseFlexibleDate(pastEvents[j].Date)
+	selected, selectErr := lib.SelectMostRecentEvent(append([]lib.EventInfo(nil), events...), eventTopic, lookbackDays, opts.Strict)
 
+	sort.SliceStable(events, func(i, j int) bool {
+		date1, err1 := lib.ParseFlexibleDate(events[i].Date)
+		date2, err2 := lib.ParseFlexibleDate(events[j].Date)
 		if err1 == nil && err2 == nil {
-			return date1.After(date2)
+			return date1.Before(date2)
 		}
-		// If parsing fails, do string comparison
-		return pastEvents[i].Date > pastEvents[j].Date
+		return events[i].Date < events[j].Date
 	})
 
-	return pastEvents[0], nil
-}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tDATE\tTOPIC\tSPEAKER")
+	for _, event := range events {
+		marker := ""
+		if selectErr == nil && event == selected {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, event.Date, event.Topic, event.Speaker)
+	}
+	w.Flush()
 
-func parseFlexibleDate(dateStr string) (time.Time, error) {
-	formats := []string{
-		"01/02/2006",
-		"1/2/2006",
-		"1/02/2006",
-		"01/2/2006",
-		"2006-01-02",
-		"January 2, 2006",
-		"Jan 2, 2006",
-		"2 January 2006",
-		"2 Jan 2006",
+	if selectErr != nil {
+		fmt.Printf("\nNo event would be selected: %v\n", selectErr)
+	} else {
+		fmt.Println("\n* most recent past event, used for certificates")
 	}
+	return nil
+}
 
-	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
-			return t, nil
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
 		}
 	}
+	return false
+}
+
+// stdin is shared by every prompt so buffered input meant for a later
+// question isn't lost when piping answers in.
+var stdin = bufio.NewReader(os.Stdin)
 
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
+// describeReadError adds the header row that was actually found to a
+// spreadsheet shape error, which is usually all a volunteer needs to spot a
+// renamed or missing column.
+func describeReadError(err error) string {
+	var parseErr *lib.ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, lib.ErrMissingColumns) {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v\n  Headers found: %s", err, parseErr.HeaderSummary())
 }
 
-func readRoster(filepath string) (map[string]string, error) {
-	f, err := excelize.OpenFile(filepath)
+// confirm asks a yes/no question on stdin, defaulting to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := stdin.ReadString('\n')
 	if err != nil {
-		return nil, err
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// sendCertificateEmail emails one or more certificates to the address they
+// share. Several certificates go out together under -group-by-email, with
+// every attendee named in the greeting.
+func sendCertificateEmail(ctx context.Context, config EmailConfig, event lib.EventInfo, entries []zipEntry) error {
+	// Create email message
+	m := gomail.NewMessage()
+
+	first := entries[0].Attendee
+	recipient := first.Email
+	if recipient == "" {
+		return fmt.Errorf("no email address for attendee %s", first.Name)
 	}
-	defer f.Close()
 
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("no sheets found in roster file")
+	var names, greetings, notes []string
+	for _, entry := range entries {
+		names = append(names, entry.Attendee.Name)
+		greetings = append(greetings, config.greetingName(entry.Attendee))
+		note, err := renderNote(config.Note, entry.Attendee)
+		if err != nil {
+			return fmt.Errorf("failed to render email note: %v", err)
+		}
+		if note != "" && !containsString(notes, note) {
+			notes = append(notes, note)
+		}
 	}
 
-	rows, err := f.GetRows(sheets[0])
+	// Set email headers
+	m.SetHeader("From", config.Email)
+	m.SetHeader("To", recipient)
+	var subject strings.Builder
+	err := config.Subject.Execute(&subject, SubjectData{
+		Name:    joinNames(names),
+		Date:    event.DisplayDate,
+		Topic:   event.Topic,
+		Speaker: event.Speaker,
+		Fields:  first.Fields,
+	})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to render subject: %v", err)
+	}
+	m.SetHeader("Subject", subject.String())
+
+	note := strings.Join(notes, " ")
+	var noteParagraph string
+	if note != "" {
+		noteParagraph = note + "\n\n"
+	}
+	var surveyParagraph string
+	if config.SurveyURL != "" {
+		surveyParagraph = "Please share your feedback: " + config.SurveyURL + "\n\n"
+	}
+	certificates := "Certificate of Attendance"
+	if len(entries) > 1 {
+		certificates = "Certificates of Attendance"
 	}
 
-	nameToEmail := make(map[string]string)
-	nameCol, emailCol := -1, -1
+	// Create email body
+	body := fmt.Sprintf(`Dear %s,
 
-	// Find Name and Email columns
-	if len(rows) > 0 {
-		for i, cell := range rows[0] {
-			cellLower := strings.ToLower(cell)
-			if cellLower == "name" {
-				nameCol = i
-			} else if strings.Contains(cellLower, "email") {
-				emailCol = i
-			}
+Please find attached your %s for the Little Rock Engineers Club presentation:
+
+Speaker: %s
+Topic: %s
+Date: %s
+
+%sThank you for attending this presentation.
+
+%sBest regards,
+Little Rock Engineers Club`, joinNames(greetings), certificates, event.Speaker, event.Topic, event.DisplayDate, noteParagraph, surveyParagraph)
+
+	m.SetBody("text/plain", body)
+	if config.Logo != "" {
+		if err := addLogoAlternative(m, config, event, joinNames(greetings), certificates, note); err != nil {
+			return err
 		}
 	}
 
-	if nameCol == -1 || emailCol == -1 {
-		return nil, fmt.Errorf("Name or Email column not found in roster")
+	// Attach each certificate
+	var attached []string
+	if config.Logo != "" {
+		attached = append(attached, config.Logo)
 	}
-
-	// Read name-email mappings (skip header row)
-	for i := 1; i < len(rows); i++ {
-		if len(rows[i]) > nameCol && len(rows[i]) > emailCol {
-			name := strings.TrimSpace(rows[i][nameCol])
-			email := strings.TrimSpace(rows[i][emailCol])
-			if name != "" && email != "" {
-				// Convert name to match attendance format
-				name = convertNameFormat(name)
-				nameToEmail[name] = email
-			}
-		}
+	for _, entry := range entries {
+		m.Attach(entry.Path)
+		attached = append(attached, entry.Path)
+	}
+	if err := checkAttachmentSize(attached, config.AttachmentMax); err != nil {
+		return err
 	}
 
-	return nameToEmail, nil
+	// Send email
+	return sendMessage(ctx, config, m)
 }
 
-func matchAttendeesWithEmails(attendees []Attendee, roster map[string]string) []Attendee {
-	for i, attendee := range attendees {
-		if email, found := roster[attendee.Name]; found {
-			attendees[i].Email = email
-		}
+// eventOutputDir groups one event's certificates, and the ZIP and checksums
+// that go with them, under certificates/<YYYY-MM-DD>-<topic-slug>.
+func eventOutputDir(event lib.EventInfo) string {
+	date := strings.ReplaceAll(event.Date, "/", "-")
+	if parsed, err := lib.ParseFlexibleDate(event.Date); err == nil {
+		date = parsed.Format("2006-01-02")
 	}
-	return attendees
+	return filepath.Join("certificates", date+"-"+lib.Slugify(event.Topic))
+}
+
+type zipEntry struct {
+	Attendee lib.Attendee
+	Path     string
 }
 
-func generateCertificate(attendee Attendee, event EventInfo, outputDir string) (string, error) {
-	// Create PDF in landscape orientation - US Letter
-	pdf := gofpdf.New("L", "mm", "Letter", "")
-	pdf.AddPage()
-
-	// Set up the certificate layout
-	pageWidth, _ := pdf.GetPageSize()
-
-	// Add skyline image at the top left
-	skylinePath := "../scripts/skyline.png"
-	imageInfo := pdf.RegisterImage(skylinePath, "PNG")
-	if imageInfo != nil {
-		// Place skyline image at top left
-		pdf.ImageOptions(skylinePath, 25, 15, 50, 0, false, gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}, 0, "")
-
-		// Add "LITTLE ROCK ENGINEERS CLUB" text next to skyline at top - same font size as name (24pt)
-		pdf.SetFont("Times", "B", 24)
-		pdf.SetXY(80, 25)
-		pdf.Cell(0, 10, "LITTLE ROCK ENGINEERS CLUB")
-	}
-
-	// Add main title - large and centered (moved closer to header)
-	pdf.SetFont("Times", "B", 36)
-	pdf.SetXY(0, 55)
-	pdf.SetTextColor(0, 0, 0)
-	titleWidth := pdf.GetStringWidth("CERTIFICATE OF ATTENDANCE")
-	titleX := (pageWidth - titleWidth) / 2
-	pdf.SetX(titleX)
-	pdf.Cell(titleWidth, 15, "CERTIFICATE OF ATTENDANCE")
-
-	// Add certification text - centered (moved up 25mm = 1 inch)
-	pdf.SetFont("Times", "", 18)
-	pdf.SetXY(0, 70)
-	certTextWidth := pdf.GetStringWidth("This is to certify that")
-	certTextX := (pageWidth - certTextWidth) / 2
-	pdf.SetX(certTextX)
-	pdf.Cell(certTextWidth, 10, "This is to certify that")
-
-	// Add attendee name with underline - properly centered with center alignment (moved up 25mm)
-	pdf.SetFont("Times", "B", 24)
-	pdf.SetXY(0, 95)
-	// Use CellFormat with center alignment for proper centering
-	pdf.CellFormat(pageWidth, 10, attendee.Name, "", 0, "C", false, 0, "")
-	// Draw underline centered under the name
-	nameWidth := pdf.GetStringWidth(attendee.Name)
-	nameX := (pageWidth - nameWidth) / 2
-	pdf.Line(nameX, 107, nameX+nameWidth, 107)
-
-	// Add earned PDH text - centered (moved up 25mm)
-	pdf.SetFont("Times", "", 16)
-	pdf.SetXY(0, 120)
-	pdhText := "Earned one (1) Professional Development Hour (PDH) by attending"
-	pdhWidth := pdf.GetStringWidth(pdhText)
-	pdhX := (pageWidth - pdhWidth) / 2
-	pdf.SetX(pdhX)
-	pdf.Cell(pdhWidth, 10, pdhText)
-
-	pdf.SetXY(0, 135)
-	presentationText := "the presentation by:"
-	presentationWidth := pdf.GetStringWidth(presentationText)
-	presentationX := (pageWidth - presentationWidth) / 2
-	pdf.SetX(presentationX)
-	pdf.Cell(presentationWidth, 10, presentationText)
-
-	// Add speaker and title - centered (moved up 25mm)
-	pdf.SetFont("Times", "I", 18)
-	pdf.SetXY(0, 150)
-	speakerWidth := pdf.GetStringWidth(event.Speaker)
-	speakerX := (pageWidth - speakerWidth) / 2
-	pdf.SetX(speakerX)
-	pdf.Cell(speakerWidth, 10, event.Speaker)
-
-	pdf.SetXY(0, 165)
-	topicWidth := pdf.GetStringWidth(event.Topic)
-	topicX := (pageWidth - topicWidth) / 2
-	pdf.SetX(topicX)
-	pdf.Cell(topicWidth, 10, event.Topic)
-
-	// Add location and date - centered (moved up 25mm)
-	pdf.SetFont("Times", "", 16)
-	pdf.SetXY(0, 185)
-	locationText := fmt.Sprintf("Conducted in Little Rock, Arkansas on %s", event.Date)
-	locationWidth := pdf.GetStringWidth(locationText)
-	locationX := (pageWidth - locationWidth) / 2
-	pdf.SetX(locationX)
-	pdf.Cell(locationWidth, 10, locationText)
-
-	// Generate filename
-	cleanName := strings.ReplaceAll(attendee.Name, " ", "_")
+// createCertificateZip bundles the generated certificates into a single ZIP
+// named by event date, along with a manifest.csv mapping files to attendees.
+func createCertificateZip(entries []zipEntry, event lib.EventInfo, outputDir string) (string, error) {
 	cleanDate := strings.ReplaceAll(event.Date, "/", "-")
-	filename := fmt.Sprintf("COA_%s_%s.pdf", cleanName, cleanDate)
-	filepath := filepath.Join(outputDir, filename)
+	zipPath := filepath.Join(outputDir, fmt.Sprintf("COA_%s.zip", cleanDate))
 
-	err := pdf.OutputFileAndClose(filepath)
+	file, err := os.Create(zipPath)
 	if err != nil {
 		return "", err
 	}
-	return filepath, nil
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+
+	manifest, err := zw.Create("manifest.csv")
+	if err != nil {
+		return "", err
+	}
+	names := zipEntryNames(entries)
+	mw := csv.NewWriter(manifest)
+	mw.Write([]string{"File", "Name", "Email"})
+	for i, entry := range entries {
+		mw.Write([]string{names[i], entry.Attendee.Name, entry.Attendee.Email})
+	}
+	mw.Flush()
+	if err := mw.Error(); err != nil {
+		return "", err
+	}
+
+	for i, entry := range entries {
+		if err := addFileToZip(zw, entry.Path, names[i]); err != nil {
+			return "", err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return zipPath, nil
 }
 
-func sendIndividualCertificateEmail(config EmailConfig, event EventInfo, attendee Attendee, certificatePath string) error {
-	// Create email message
-	m := gomail.NewMessage()
+// zipEntryNames returns the name each certificate is stored under in the
+// ZIP: its file name, with _2, _3, and so on added when attendees share a
+// name. Names are compared ignoring case, as Windows and macOS unzip them.
+func zipEntryNames(entries []zipEntry) []string {
+	names := make([]string, len(entries))
+	taken := map[string]bool{"manifest.csv": true}
+	for i, entry := range entries {
+		base := filepath.Base(entry.Path)
+		ext := filepath.Ext(base)
+		name := base
+		for n := 2; taken[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		taken[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
 
-	recipient := attendee.Email
-	if recipient == "" {
-		return fmt.Errorf("no email address for attendee %s", attendee.Name)
+func addFileToZip(zw *zip.Writer, path string, name string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
 	}
+	defer src.Close()
+
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func sendCertificateZipEmail(ctx context.Context, config EmailConfig, event lib.EventInfo, recipient string, zipPath string, count int) error {
+	m := gomail.NewMessage()
 
-	// Set email headers
 	m.SetHeader("From", config.Email)
 	m.SetHeader("To", recipient)
-	m.SetHeader("Subject", fmt.Sprintf("LREC Certificate of Attendance - %s - %s", attendee.Name, event.Date))
+	m.SetHeader("Subject", fmt.Sprintf("LREC Certificates of Attendance - %s", event.DisplayDate))
 
-	// Create email body
-	body := fmt.Sprintf(`Dear %s,
+	body := fmt.Sprintf(`Hello,
 
-Please find attached your Certificate of Attendance for the Little Rock Engineers Club presentation:
+Attached are %d Certificates of Attendance for the Little Rock Engineers Club presentation:
 
 Speaker: %s
 Topic: %s
 Date: %s
 
-Thank you for attending this presentation.
+The included manifest.csv lists each certificate file and its attendee.
 
 Best regards,
-Little Rock Engineers Club`, attendee.Name, event.Speaker, event.Topic, event.Date)
+Little Rock Engineers Club`, count, event.Speaker, event.Topic, event.DisplayDate)
 
 	m.SetBody("text/plain", body)
-
-	// Attach the individual certificate
-	m.Attach(certificatePath)
-
-	// Create SMTP dialer
-	d := gomail.NewDialer(config.SMTPHost, config.SMTPPort, config.Email, config.AppPassword)
-
-	// Send email
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+	m.Attach(zipPath)
+	if err := checkAttachmentSize([]string{zipPath}, config.AttachmentMax); err != nil {
+		return err
 	}
 
-	return nil
+	return sendMessage(ctx, config, m)
 }
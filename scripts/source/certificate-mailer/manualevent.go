@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// manualEvent builds the event from the -event-* flags, for an ad-hoc
+// session that isn't on the calendar. The date may be in any format the
+// calendar accepts; topic and speaker are required since every design
+// prints them.
+func manualEvent(topic, speaker, date, location, clock string) (lib.EventInfo, error) {
+	event := lib.EventInfo{
+		Date:     strings.TrimSpace(date),
+		Topic:    strings.TrimSpace(topic),
+		Speaker:  strings.TrimSpace(speaker),
+		Location: strings.TrimSpace(location),
+		Time:     strings.TrimSpace(clock),
+	}
+	if event.Topic == "" || event.Speaker == "" {
+		return lib.EventInfo{}, fmt.Errorf("-event-date needs -event-topic and -event-speaker too")
+	}
+	if _, err := lib.ParseFlexibleDate(event.Date); err != nil {
+		return lib.EventInfo{}, fmt.Errorf("-event-date: %v", err)
+	}
+	return event, nil
+}
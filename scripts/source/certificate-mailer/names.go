@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// truncateName shortens name to at most max characters, ending in "...".
+func truncateName(name string, max int) string {
+	runes := []rune(name)
+	if len(runes) <= max {
+		return name
+	}
+	if max <= 3 {
+		return string(runes[:max])
+	}
+	return strings.TrimSpace(string(runes[:max-3])) + "..."
+}
+
+// checkNameLengths applies the -on-bad-name policy to attendees whose names
+// are longer than max characters, which usually means a corrupted export put
+// other text in the name column. With "truncate" the name is shortened;
+// otherwise the attendee is dropped and returned in skipped. A max of 0
+// disables the check.
+func checkNameLengths(attendees []lib.Attendee, max int, policy string) (kept, skipped []lib.Attendee) {
+	if max <= 0 {
+		return attendees, nil
+	}
+	for _, attendee := range attendees {
+		length := len([]rune(attendee.Name))
+		if length <= max {
+			kept = append(kept, attendee)
+			continue
+		}
+		short := truncateName(attendee.Name, max)
+		if policy == "truncate" {
+			log.Printf("Warning: name is %d characters, over -max-name-length %d; truncating to %s", length, max, pii.Name(short))
+			attendee.Name = short
+			kept = append(kept, attendee)
+		} else {
+			log.Printf("Warning: name is %d characters, over -max-name-length %d; skipping %s", length, max, pii.Name(short))
+			skipped = append(skipped, attendee)
+		}
+	}
+	return kept, skipped
+}
+
+// uncertainGreeting reports whether greeting, the part of an attendee's
+// name chosen to follow "Dear", is likely to read badly: a one-word name,
+// which may be only a surname, a name in all capitals such as "SMITH", or a
+// bare initial.
+func uncertainGreeting(greeting, name string) bool {
+	full, _, _ := strings.Cut(name, ",")
+	if len(strings.Fields(greeting)) == 1 && len(strings.Fields(full)) == 1 {
+		return true
+	}
+	if len([]rune(strings.TrimSuffix(greeting, "."))) == 1 {
+		return true
+	}
+	letters := 0
+	for _, r := range greeting {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters > 1
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// certificateNumbers hands out sequential certificate numbers, such as
+// LREC-2025-0001, for -number-prefix. The registry is the counter: the
+// next number follows the highest one issued with the prefix, and a
+// certificate already in the registry keeps the number it was given.
+type certificateNumbers struct {
+	prefix string
+	last   int
+	byID   map[string]string
+	fresh  map[string]bool
+}
+
+// loadCertificateNumbers picks up the numbers already issued in the
+// registry at path.
+func loadCertificateNumbers(path, prefix string) (*certificateNumbers, error) {
+	registry, err := readRegistry(path)
+	if err != nil {
+		return nil, err
+	}
+	n := &certificateNumbers{
+		prefix: prefix,
+		byID:   make(map[string]string),
+		fresh:  make(map[string]bool),
+	}
+	for id, entry := range registry {
+		if entry.Number == "" {
+			continue
+		}
+		n.byID[id] = entry.Number
+		if seq, err := strconv.Atoi(strings.TrimPrefix(entry.Number, prefix)); err == nil && strings.HasPrefix(entry.Number, prefix) && seq > n.last {
+			n.last = seq
+		}
+	}
+	return n, nil
+}
+
+// assign returns the certificate's number, giving it the next one in
+// sequence if it has none yet.
+func (n *certificateNumbers) assign(id string) string {
+	if number, ok := n.byID[id]; ok {
+		return number
+	}
+	n.last++
+	number := fmt.Sprintf("%s%04d", n.prefix, n.last)
+	n.byID[id] = number
+	n.fresh[id] = true
+	return number
+}
+
+// isNew reports whether assign gave id its number during this run.
+func (n *certificateNumbers) isNew(id string) bool {
+	return n.fresh[id]
+}
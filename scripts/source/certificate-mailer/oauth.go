@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const googleTokenURL = "https://oauth2.googleapis.com/token"
+
+// oauthCredentials holds the client credentials and refresh token used to
+// obtain Gmail access tokens for XOAUTH2. The current access token is cached
+// so a run only refreshes it when it is about to expire.
+type oauthCredentials struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// oauthFromEnv returns OAuth credentials from GMAIL_OAUTH_CLIENT_ID,
+// GMAIL_OAUTH_CLIENT_SECRET and GMAIL_OAUTH_REFRESH_TOKEN, each with the
+// account's suffix (see accountSuffixes), or nil if they aren't all set, in
+// which case the app password is used instead.
+func oauthFromEnv(suffix string) *oauthCredentials {
+	creds := &oauthCredentials{
+		ClientID:     os.Getenv("GMAIL_OAUTH_CLIENT_ID" + suffix),
+		ClientSecret: os.Getenv("GMAIL_OAUTH_CLIENT_SECRET" + suffix),
+		RefreshToken: os.Getenv("GMAIL_OAUTH_REFRESH_TOKEN" + suffix),
+	}
+	if creds.ClientID == "" || creds.ClientSecret == "" || creds.RefreshToken == "" {
+		return nil
+	}
+	return creds
+}
+
+// token returns a valid access token, refreshing it if needed. The refresh
+// request is abandoned when ctx is done.
+func (c *oauthCredentials) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.expiry) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"refresh_token": {c.RefreshToken},
+		"grant_type":    {"refresh_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh OAuth token: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("failed to refresh OAuth token: %w", context.Cause(ctx))
+		}
+		return "", fmt.Errorf("failed to refresh OAuth token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to read OAuth token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("failed to refresh OAuth token: %s %s", result.Error, result.ErrorDescription)
+	}
+
+	c.accessToken = result.AccessToken
+	// Refresh a minute early so a token never expires mid-send
+	c.expiry = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return c.accessToken, nil
+}
+
+// xoauth2Auth implements smtp.Auth for Gmail's XOAUTH2 mechanism.
+type xoauth2Auth struct {
+	username string
+	token    string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		return "", nil, fmt.Errorf("XOAUTH2 requires an encrypted connection")
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		// On failure the server sends a JSON error as a challenge; an empty
+		// reply lets it finish with the real error code
+		return []byte{}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import "strings"
+
+// pathList is a flag that may be repeated, collecting one path per use.
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *pathList) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// registryEntry is what the published registry says about one certificate.
+// Emails are left out since the file is meant to be served publicly.
+type registryEntry struct {
+	Name        string  `json:"name"`
+	Title       string  `json:"title,omitempty"`
+	Date        string  `json:"date"`
+	DisplayDate string  `json:"display_date,omitempty"` // as printed, after -date-format and -lang
+	Topic       string  `json:"topic"`
+	Speaker     string  `json:"speaker"`
+	Location    string  `json:"location,omitempty"`
+	Time        string  `json:"time,omitempty"`
+	Abstract    string  `json:"abstract,omitempty"`
+	Note        string  `json:"note,omitempty"`
+	PDH         float64 `json:"pdh"`
+	Design      string  `json:"design,omitempty"`
+	Lang        string  `json:"lang,omitempty"`
+	Number      string  `json:"number,omitempty"` // sequential number from -number-prefix
+	Issued      string  `json:"issued"`
+}
+
+func newRegistryEntry(attendee lib.Attendee, event lib.EventInfo, config lib.CertificateConfig) registryEntry {
+	return registryEntry{
+		Name:        attendee.Name,
+		Title:       attendee.Title,
+		Date:        event.Date,
+		DisplayDate: event.DisplayDate,
+		Topic:       event.Topic,
+		Speaker:     event.Speaker,
+		Location:    event.Location,
+		Time:        event.Time,
+		Abstract:    event.Abstract,
+		Note:        config.Note,
+		PDH:         attendee.PDH,
+		Design:      config.Design,
+		Lang:        config.Lang,
+		Number:      config.Number,
+		Issued:      time.Now().Format("2006-01-02"),
+	}
+}
+
+// readRegistry loads a registry file, returning an empty registry if it
+// doesn't exist yet.
+func readRegistry(path string) (map[string]registryEntry, error) {
+	registry := make(map[string]registryEntry)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse registry %s: %v", path, err)
+	}
+	return registry, nil
+}
+
+// exportRegistry merges this run's certificates into the registry at path.
+// Entries from earlier events are kept; reissued IDs keep their original
+// issue date.
+func exportRegistry(path string, issued map[string]registryEntry) error {
+	registry, err := readRegistry(path)
+	if err != nil {
+		return err
+	}
+	for id, entry := range issued {
+		if existing, ok := registry[id]; ok {
+			entry.Issued = existing.Issued
+		}
+		registry[id] = entry
+	}
+
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so a failed write never truncates the
+	// registry that is already published
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".registry-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// reissueCertificate regenerates the certificate for one registry entry
+// without the original spreadsheets. The issue date and design come from the
+// registry, so with the same signature options the PDF matches the original
+// byte for byte. format is the -format to render in.
+func reissueCertificate(registryPath, id string, config lib.CertificateConfig, dateFormat, format, outputDir string) (string, error) {
+	registry, err := readRegistry(registryPath)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := registry[id]
+	if !ok {
+		return "", fmt.Errorf("certificate %s is not in %s", id, registryPath)
+	}
+
+	attendee := lib.Attendee{Name: entry.Name, Title: entry.Title, PDH: entry.PDH}
+	// Registries written before per-attendee PDH have no pdh field
+	if attendee.PDH <= 0 {
+		attendee.PDH = lib.DefaultPDH
+	}
+	event := lib.EventInfo{
+		Date:     entry.Date,
+		Topic:    entry.Topic,
+		Speaker:  entry.Speaker,
+		Location: entry.Location,
+		Time:     entry.Time,
+		Abstract: entry.Abstract,
+	}
+	if entry.Lang != "" {
+		config.Lang = entry.Lang
+	}
+	config.Note = entry.Note
+	config.Number = entry.Number
+	event.DisplayDate = entry.DisplayDate
+	if event.DisplayDate == "" {
+		event.DisplayDate = lib.FormatEventDate(event.Date, dateFormat, config.Lang)
+	}
+
+	// The ID is derived from these fields, so a mismatch means the entry was
+	// edited by hand
+	if lib.CertificateID(attendee, event) != id {
+		return "", fmt.Errorf("registry entry for %s doesn't match its ID", id)
+	}
+
+	config.Issued, err = time.ParseInLocation("2006-01-02", entry.Issued, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("bad issue date for %s: %v", id, err)
+	}
+	if entry.Design != "" {
+		config.Design = entry.Design
+	}
+	if _, ok := lib.Designs[config.Design]; !ok {
+		return "", fmt.Errorf("unknown design %q for %s", config.Design, id)
+	}
+	return generateCertificate(format, config, attendee, event, outputDir)
+}
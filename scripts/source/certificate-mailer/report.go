@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// reportEntry is one attendee's latest outcome in a run report.
+type reportEntry struct {
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Certificate string `json:"certificate,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Updated     string `json:"updated"`
+}
+
+// runReport is the JSON report written by -report and read back by -resume.
+// It is rewritten after every outcome so a run that dies partway still
+// leaves an accurate record. A nil *runReport discards records.
+type runReport struct {
+	path string
+
+	EventDate  string                 `json:"event_date"`
+	EventTopic string                 `json:"event_topic"`
+	Attendees  map[string]reportEntry `json:"attendees"` // keyed by certificate ID
+}
+
+// readReport loads a report written by an earlier run.
+func readReport(path string) (*runReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report runReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %v", path, err)
+	}
+	if report.Attendees == nil {
+		report.Attendees = make(map[string]reportEntry)
+	}
+	return &report, nil
+}
+
+// newReport starts the report for event at path. Entries from prior (a
+// resumed run's report, or nil) are carried over so the finished report
+// covers the whole event, not just the attendees retried this time.
+func newReport(path string, event lib.EventInfo, prior *runReport) *runReport {
+	report := &runReport{
+		path:       path,
+		EventDate:  event.Date,
+		EventTopic: event.Topic,
+		Attendees:  make(map[string]reportEntry),
+	}
+	if prior != nil {
+		for id, entry := range prior.Attendees {
+			report.Attendees[id] = entry
+		}
+	}
+	return report
+}
+
+// sent reports whether the attendee's certificate was delivered by the run
+// that wrote the report.
+func (r *runReport) sent(attendee lib.Attendee, event lib.EventInfo) bool {
+	return r.Attendees[lib.CertificateID(attendee, event)].Status == "sent"
+}
+
+// Record saves one attendee's outcome and rewrites the report. Like the send
+// log, it keeps going if the write fails; the error is only logged.
+func (r *runReport) Record(attendee lib.Attendee, event lib.EventInfo, certificatePath, status string, err error) {
+	if r == nil {
+		return
+	}
+	errText := ""
+	if err != nil {
+		errText = err.Error()
+	}
+	r.Attendees[lib.CertificateID(attendee, event)] = reportEntry{
+		Name:        attendee.Name,
+		Email:       attendee.Email,
+		Certificate: certificatePath,
+		Status:      status,
+		Error:       errText,
+		Updated:     time.Now().Format(time.RFC3339),
+	}
+	if err := r.write(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write report %s: %v\n", r.path, err)
+	}
+}
+
+// write replaces the report file atomically, so a crash mid-write leaves the
+// previous version for -resume rather than a truncated file.
+func (r *runReport) write() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".report-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
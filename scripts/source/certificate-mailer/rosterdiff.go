@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// diffRosters compares two rosters read with the usual name normalization
+// and prints who was added, who was removed, and whose email changed.
+// Email case and surrounding spaces don't count as a change.
+func diffRosters(oldPath, newPath string, format lib.NameFormat, opts lib.ReadOptions) error {
+	oldRoster, err := lib.ReadRoster(oldPath, format, opts)
+	if err != nil {
+		return err
+	}
+	newRoster, err := lib.ReadRoster(newPath, format, opts)
+	if err != nil {
+		return err
+	}
+
+	var added, removed, changed []string
+	for name, entry := range newRoster {
+		old, ok := oldRoster[name]
+		if !ok {
+			added = append(added, name)
+		} else if emailKey(old.Email) != emailKey(entry.Email) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range oldRoster {
+		if _, ok := newRoster[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+
+	fmt.Printf("Comparing %s (%d members) with %s (%d members)\n", oldPath, len(oldRoster), newPath, len(newRoster))
+
+	fmt.Printf("\nAdded (%d):\n", len(added))
+	for _, name := range added {
+		fmt.Printf("  + %s (%s)\n", pii.Name(name), displayEmail(newRoster[name].Email))
+	}
+	fmt.Printf("\nRemoved (%d):\n", len(removed))
+	for _, name := range removed {
+		fmt.Printf("  - %s (%s)\n", pii.Name(name), displayEmail(oldRoster[name].Email))
+	}
+	fmt.Printf("\nChanged email (%d):\n", len(changed))
+	for _, name := range changed {
+		fmt.Printf("  ~ %s: %s -> %s\n", pii.Name(name), displayEmail(oldRoster[name].Email), displayEmail(newRoster[name].Email))
+	}
+	return nil
+}
+
+// displayEmail masks an email for the console and marks a blank one.
+func displayEmail(email string) string {
+	if email == "" {
+		return "(none)"
+	}
+	return pii.Email(email)
+}
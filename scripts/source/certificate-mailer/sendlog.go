@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// sendLog appends one CSV row per attendee with the full, unredacted outcome
+// of the run. It is meant to live somewhere access-controlled. A nil
+// *sendLog discards records.
+type sendLog struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// sendLogHeader names the columns Record writes.
+var sendLogHeader = []string{"Timestamp", "Name", "Email", "Certificate", "Status", "Error", "Account"}
+
+func openSendLog(path string) (*sendLog, error) {
+	if err := upgradeSendLog(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &sendLog{file: file, writer: csv.NewWriter(file)}
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		l.writer.Write(sendLogHeader)
+	}
+	return l, nil
+}
+
+// upgradeSendLog adds the Account column, with every earlier row blank, to a
+// log written before it existed, so appending doesn't leave rows with more
+// fields than the header. The log is rewritten alongside and renamed into
+// place, so an interrupted upgrade leaves the original untouched.
+func upgradeSendLog(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("reading %s: %v", path, err)
+	}
+	if len(records) == 0 || len(records[0]) >= len(sendLogHeader) {
+		return nil
+	}
+	if len(records[0]) != len(sendLogHeader)-1 {
+		return fmt.Errorf("%s has %d columns, not the %d of a send log", path, len(records[0]), len(sendLogHeader))
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	writer := csv.NewWriter(temp)
+	writer.Write(sendLogHeader)
+	for _, record := range records[1:] {
+		writer.Write(append(record, ""))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+	fmt.Printf("Added an Account column to the send log %s\n", path)
+	return nil
+}
+
+// Record logs one attendee's outcome. account is the address the email was
+// sent from, or "" if nothing was sent.
+func (l *sendLog) Record(attendee lib.Attendee, certificatePath, account, status string, err error) {
+	if l == nil {
+		return
+	}
+	errText := ""
+	if err != nil {
+		errText = err.Error()
+	}
+	l.writer.Write([]string{time.Now().Format(time.RFC3339), attendee.Name, attendee.Email, certificatePath, status, errText, account})
+	l.writer.Flush()
+}
+
+func (l *sendLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.writer.Flush()
+	if err := l.writer.Error(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
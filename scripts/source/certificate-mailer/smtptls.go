@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+)
+
+// smtpTLSModes are the accepted -smtp-tls values:
+//
+//	starttls  connect in plain text and upgrade with STARTTLS (port 587)
+//	ssl       TLS from the first byte, also called SMTPS (port 465)
+//	none      no TLS is set up by us; STARTTLS is still used if the relay
+//	          offers it. Password authentication over a plain connection is
+//	          only allowed to localhost, so this suits unauthenticated relays
+var smtpTLSModes = map[string]int{
+	"starttls": 587,
+	"ssl":      465,
+	"none":     25,
+}
+
+// smtpTLSConfig builds the TLS settings for the SMTP dialer. caPath adds a
+// PEM CA bundle to the system roots, for relays with a private CA.
+//
+// insecure turns off certificate verification entirely. The connection is
+// still encrypted, but anyone on the network path can impersonate the relay
+// and read the credentials and every certificate email, so it is only meant
+// for testing against a relay you control.
+func smtpTLSConfig(host, caPath string, insecure bool) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host}
+
+	if caPath != "" {
+		pem, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caPath)
+		}
+		config.RootCAs = pool
+	}
+
+	if insecure {
+		log.Printf("Warning: -smtp-insecure disables certificate checks; the relay's identity is not verified")
+		config.InsecureSkipVerify = true
+	}
+	return config, nil
+}
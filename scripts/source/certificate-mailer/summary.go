@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+	"gopkg.in/gomail.v2"
+)
+
+// runFailure records one attendee that didn't get a certificate delivered.
+type runFailure struct {
+	Attendee lib.Attendee
+	Step     string
+	Err      error
+}
+
+// runSummary is the outcome of a run, reported to organizers by -summary-to.
+type runSummary struct {
+	Attendees int
+	Generated int
+	Sent      int
+	ZipTo     string
+	Failures  []runFailure
+}
+
+func (s runSummary) Body(event lib.EventInfo) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Certificate run summary for the Little Rock Engineers Club presentation:\n\n")
+	fmt.Fprintf(&b, "Speaker: %s\nTopic: %s\nDate: %s\n\n", event.Speaker, event.Topic, event.DisplayDate)
+
+	fmt.Fprintf(&b, "Attendees: %d\n", s.Attendees)
+	fmt.Fprintf(&b, "Certificates generated: %d\n", s.Generated)
+	if s.ZipTo != "" {
+		fmt.Fprintf(&b, "Certificates bundled and sent as a ZIP to %s\n", s.ZipTo)
+	} else {
+		fmt.Fprintf(&b, "Emails sent: %d\n", s.Sent)
+	}
+	fmt.Fprintf(&b, "Failures: %d\n", len(s.Failures))
+
+	if len(s.Failures) > 0 {
+		fmt.Fprintf(&b, "\nFailed attendees:\n")
+		for _, failure := range s.Failures {
+			fmt.Fprintf(&b, "  - %s <%s> (%s): %v\n", failure.Attendee.Name, failure.Attendee.Email, failure.Step, failure.Err)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nBest regards,\nLittle Rock Engineers Club")
+	return b.String()
+}
+
+func sendSummaryEmail(ctx context.Context, config EmailConfig, event lib.EventInfo, recipient string, summary runSummary) error {
+	m := gomail.NewMessage()
+
+	m.SetHeader("From", config.Email)
+	m.SetHeader("To", recipient)
+	m.SetHeader("Subject", fmt.Sprintf("LREC Certificate Run Summary - %s - %d sent, %d failed", event.DisplayDate, summary.Sent, len(summary.Failures)))
+	m.SetBody("text/plain", summary.Body(event))
+
+	return sendMessage(ctx, config, m)
+}
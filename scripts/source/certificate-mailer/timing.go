@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// runTimer accumulates where a run spends its time, to help tune -rate.
+// time.Since uses the monotonic clock, so wall-clock changes mid-run don't
+// skew the numbers.
+type runTimer struct {
+	start      time.Time
+	generating time.Duration
+	generated  int
+	sending    time.Duration
+	sent       int
+}
+
+func newRunTimer() *runTimer {
+	return &runTimer{start: time.Now()}
+}
+
+// generation records one certificate that took since start to produce.
+func (t *runTimer) generation(start time.Time) {
+	t.generating += time.Since(start)
+	t.generated++
+}
+
+// send records one message that took since start to deliver, not counting
+// any -rate wait before it.
+func (t *runTimer) send(start time.Time) {
+	t.sending += time.Since(start)
+	t.sent++
+}
+
+func (t *runTimer) print() {
+	fmt.Printf("\nTiming:\n")
+	fmt.Printf("  Total:      %v\n", time.Since(t.start).Round(time.Millisecond))
+	fmt.Printf("  Generating: %v for %d certificate(s)\n", t.generating.Round(time.Millisecond), t.generated)
+	fmt.Printf("  Sending:    %v for %d email(s)", t.sending.Round(time.Millisecond), t.sent)
+	if t.sent > 0 {
+		fmt.Printf(", %v average", (t.sending / time.Duration(t.sent)).Round(time.Millisecond))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/gomail.v2"
+)
+
+// sendMessage delivers a composed message. Every email the tool sends goes
+// through here, so delivery options only need handling in one place. It
+// gives up when ctx is done or after config.Timeout, whichever is first.
+func sendMessage(ctx context.Context, config EmailConfig, m *gomail.Message) error {
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, config.Timeout, fmt.Errorf("no reply from the mail server within -smtp-timeout %s", config.Timeout))
+		defer cancel()
+	}
+
+	if config.Maildir != "" {
+		return writeToMaildir(config.Maildir, m)
+	}
+	if config.Sendmail != "" {
+		return sendWithSendmail(ctx, config.Sendmail, config.Email, m)
+	}
+
+	// Create SMTP dialer
+	d := gomail.NewDialer(config.SMTPHost, config.SMTPPort, config.Email, config.AppPassword)
+	d.SSL = config.SMTPS
+	d.TLSConfig = config.TLS
+	if config.OAuth != nil {
+		// The token refresh shares ctx, so a hung token endpoint is bounded
+		// by -smtp-timeout and -deadline just like the send itself
+		token, err := config.OAuth.token(ctx)
+		if err != nil {
+			return err
+		}
+		d.Auth = &xoauth2Auth{username: config.Email, token: token}
+	}
+
+	// gomail has no way to cancel a connection in progress, so a hung one is
+	// abandoned; it goes away when the process exits
+	done := make(chan error, 1)
+	go func() { done <- d.DialAndSend(m) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return classifySendError(fmt.Errorf("failed to send email: %v", err))
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send email: %w", context.Cause(ctx))
+	}
+}
+
+// writeToMaildir saves the full MIME message, attachments included, as an
+// .eml file named after its first recipient.
+func writeToMaildir(dir string, m *gomail.Message) error {
+	recipient := "message"
+	if to := m.GetHeader("To"); len(to) > 0 {
+		recipient = strings.NewReplacer("@", "_at_", "/", "_", "\\", "_", " ", "_").Replace(to[0])
+	}
+
+	file, err := os.CreateTemp(dir, recipient+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("failed to create message file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := m.WriteTo(file); err != nil {
+		return fmt.Errorf("failed to write message file: %v", err)
+	}
+	return nil
+}
+
+// sendWithSendmail pipes the message to a local sendmail-compatible program
+// such as sendmail or msmtp, which then owns delivery and any credentials.
+// Recipients are passed as arguments rather than read from the headers, so
+// Bcc addresses, which the written message leaves out, still get a copy.
+func sendWithSendmail(ctx context.Context, path, from string, m *gomail.Message) error {
+	var recipients []string
+	for _, field := range []string{"To", "Cc", "Bcc"} {
+		for _, value := range m.GetHeader(field) {
+			addr, err := mail.ParseAddress(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s address %q: %v", field, value, err)
+			}
+			recipients = append(recipients, addr.Address)
+		}
+	}
+
+	var message bytes.Buffer
+	if _, err := m.WriteTo(&message); err != nil {
+		return fmt.Errorf("failed to compose message: %v", err)
+	}
+
+	// -i keeps a line holding only "." from ending the message early
+	cmd := exec.CommandContext(ctx, path, append([]string{"-i", "-f", from, "--"}, recipients...)...)
+	cmd.Stdin = &message
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s stopped: %w", path, context.Cause(ctx))
+		}
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return fmt.Errorf("%s failed: %v: %s", path, err, detail)
+		}
+		return fmt.Errorf("%s failed: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+	"gopkg.in/gomail.v2"
+)
+
+// sendUnmatchedEmail asks the organizer to fix the roster for attendees it
+// had no valid email for and for those whose email bounced, listed with
+// the rejected address. When the run only wrote to a maildir, the message
+// says so, since none of the certificates went out either.
+func sendUnmatchedEmail(ctx context.Context, config EmailConfig, event lib.EventInfo, recipient string, unmatched, bounced []lib.Attendee) error {
+	var b strings.Builder
+	if config.Maildir != "" {
+		fmt.Fprintf(&b, "TEST RUN: messages were written to %s, not sent.\n\n", config.Maildir)
+	}
+	fmt.Fprintf(&b, "These attendees of the Little Rock Engineers Club presentation did not receive a certificate:\n\n")
+	fmt.Fprintf(&b, "Speaker: %s\nTopic: %s\nDate: %s\n\n", event.Speaker, event.Topic, event.DisplayDate)
+	if len(unmatched) > 0 {
+		fmt.Fprintf(&b, "No valid email address in the roster:\n")
+		for _, attendee := range unmatched {
+			fmt.Fprintf(&b, "  - %s\n", attendee.Name)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+	if len(bounced) > 0 {
+		fmt.Fprintf(&b, "Email bounced; the mail server rejected the roster address as undeliverable:\n")
+		for _, attendee := range bounced {
+			fmt.Fprintf(&b, "  - %s <%s>\n", attendee.Name, attendee.Email)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+	fmt.Fprintf(&b, "Please correct their addresses in the roster and rerun with -only to send their certificates.\n\nBest regards,\nLittle Rock Engineers Club")
+
+	var problems []string
+	if len(unmatched) > 0 {
+		problems = append(problems, fmt.Sprintf("%d attendee(s) missing an email", len(unmatched)))
+	}
+	if len(bounced) > 0 {
+		problems = append(problems, fmt.Sprintf("%d bounced", len(bounced)))
+	}
+	subject := fmt.Sprintf("LREC Certificates - %s - %s", strings.Join(problems, ", "), event.DisplayDate)
+	if config.Maildir != "" {
+		subject = "[TEST RUN] " + subject
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.Email)
+	m.SetHeader("To", recipient)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/plain", b.String())
+
+	return sendMessage(ctx, config, m)
+}
@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// framedTemplate is implemented by designs that draw a border, so blocks
+// placed near the page edge can stay inside it.
+type framedTemplate interface {
+	// FrameInset is the distance in mm from the page edge to the inside of
+	// the border
+	FrameInset() float64
+}
+
+// drawAccreditation prints the continuing education block: a heading, the
+// provider name and number, and the board's approval statement, in small
+// type at the bottom of the page. Without a frame it spans the page below
+// the certificate ID and signature. Inside a frame there is no room there,
+// so it sits between the ID and the signature in landscape, or above the ID
+// in portrait. The block grows upward from its baseline, so a long statement
+// never runs into the frame.
+func drawAccreditation(pdf *gofpdf.Fpdf, config CertificateConfig, design CertificateTemplate) {
+	text := textFor(config.Lang)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageWidth, pageHeight := pdf.GetPageSize()
+
+	var inset float64
+	if frame, ok := design.(framedTemplate); ok {
+		inset = frame.FrameInset()
+	}
+	x, width, bottom := accreditationBox(config, pageWidth, pageHeight, inset)
+	lineHeight := 3.0
+
+	provider := text.ProviderNo + config.ProviderNumber
+	if config.ProviderName != "" {
+		provider = text.Provider + config.ProviderName + " - " + provider
+	}
+
+	pdf.SetFont("Helvetica", "", 7)
+	lines := pdf.SplitText(tr(provider), width)
+	if config.AccreditationText != "" {
+		lines = append(lines, pdf.SplitText(tr(strings.TrimSpace(config.AccreditationText)), width)...)
+	}
+
+	y := bottom - lineHeight*float64(len(lines)+1)
+	setTextColor(pdf, config, 60, 60, 60)
+	pdf.SetFont("Helvetica", "B", 7)
+	pdf.SetXY(x, y)
+	pdf.CellFormat(width, lineHeight, tr(text.Accreditation), "", 2, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 7)
+	for _, line := range lines {
+		pdf.CellFormat(width, lineHeight, line, "", 2, "C", false, 0, "")
+	}
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// accreditationBox is where the continuing education block goes: its left
+// edge and width, and the bottom it grows up from. inset is the design's
+// FrameInset, or zero for a design without a frame.
+func accreditationBox(config CertificateConfig, pageWidth, pageHeight, inset float64) (x, width, bottom float64) {
+	if inset == 0 {
+		return 20, pageWidth - 40, pageHeight - 5
+	}
+	// Stop short of the signature block, which starts 74.4mm from the edge
+	x, bottom = 100, pageHeight-inset-2
+	if config.Orientation == "P" {
+		x, bottom = 20, pageHeight-26
+	}
+	return x, pageWidth - x - 80, bottom
+}
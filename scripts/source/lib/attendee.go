@@ -0,0 +1,481 @@
+package lib
+
+import (
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+)
+
+// Attendee is one person from the attendance sheet. Email and Title are
+// filled in from the roster by MatchAttendeesWithEmails.
+type Attendee struct {
+	Name  string
+	Email string
+	// Title holds post-nominal credentials such as "P.E." or "PhD"
+	Title string
+	// PDH is the credit earned, computed from sign-in/sign-out times when the
+	// attendance sheet has them
+	PDH float64
+	// Fields holds the roster's other columns, such as "chapter" or
+	// "member_id", keyed by FieldKey of the header
+	Fields map[string]string
+	// EmailName is the display name the roster gave with the email address,
+	// if any; see RosterEntry
+	EmailName string
+}
+
+// DisplayName is the name as printed on the certificate, with credentials.
+func (a Attendee) DisplayName() string {
+	if a.Title == "" {
+		return a.Name
+	}
+	return a.Name + ", " + a.Title
+}
+
+// FirstName is used for email greetings; see FirstName.
+func (a Attendee) FirstName() string {
+	return FirstName(a.Name)
+}
+
+// honorifics are titles that may precede a first name.
+var honorifics = map[string]bool{
+	"dr": true, "mr": true, "mrs": true, "ms": true, "miss": true, "prof": true, "rev": true,
+}
+
+// FirstName returns the given name from "First Last", "Last, First", or a
+// credentialed name such as "Dr. John Smith, PE", for personal greetings. A
+// leading initial is skipped ("J. Robert Smith" is Robert). When only an
+// honorific and surname are left, as in "Dr. Smith", or the name can't be
+// split, the name is returned whole.
+func FirstName(name string) string {
+	name = ConvertNameFormat(name)
+	full, _, _ := strings.Cut(name, ",")
+	fields := strings.Fields(full)
+	for len(fields) > 1 && honorifics[strings.ToLower(strings.TrimSuffix(fields[0], "."))] {
+		if len(fields) == 2 {
+			return strings.Join(fields, " ")
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 2 && len(strings.TrimSuffix(fields[0], ".")) == 1 {
+		fields = fields[1:]
+	}
+	if first, _ := SplitName(strings.Join(fields, " ")); first != "" {
+		return first
+	}
+	return name
+}
+
+// RosterEntry is what the roster knows about a member.
+type RosterEntry struct {
+	Email string
+	// EmailName is the display name from an email cell written as
+	// "John Smith <john@x.com>", or "" for a bare address
+	EmailName string
+	Title     string
+	Fields    map[string]string
+}
+
+// FieldKey normalizes a roster header for use as an Attendee.Fields key:
+// lowercase, with spaces and hyphens as underscores, so "Member ID" is
+// available to templates as {{.Fields.member_id}}.
+func FieldKey(header string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(header), "_"))
+	return strings.ReplaceAll(key, "-", "_")
+}
+
+// isTitleHeader reports whether a header names the optional credentials column.
+func isTitleHeader(header string) bool {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return header == "title" || HeaderHas(header, "credential")
+}
+
+// headerScanRows is how many leading rows are searched for the header row,
+// since exported sheets sometimes start with a title banner.
+const headerScanRows = 5
+
+// ReadAttendance reads attendee names, "Last, First" or "First Last", from
+// the Name or Full Name column, else from First Name and Last Name columns,
+// else from the first column whose header contains "name".
+// Optional Title/Credentials and sign_in/sign_out columns set Title and PDH,
+// and a "pdh" column, where filled in, overrides the PDH for that attendee,
+// e.g. extra credit for the speaker. Every row is returned; attendees who
+// come to 0 PDH are kept with a warning. A .txt file is a plain list of
+// names, one per line.
+func ReadAttendance(filepath string, opts ReadOptions) ([]Attendee, error) {
+	if strings.HasSuffix(strings.ToLower(filepath), ".txt") {
+		return readNameList(filepath, opts)
+	}
+	rows, err := ReadRows(filepath, "attendance", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var attendees []Attendee
+	nameCols := nameColumns{full: -1, first: -1, last: -1}
+	titleCol, pdhCol := -1, -1
+	signInCol, signOutCol := -1, -1
+	headerRow := 0
+
+	// Find the name columns and the optional Title/Credentials, PDH, and
+	// sign-in/out columns, in the first row near the top that has a Name header
+	for rowIdx := 0; rowIdx < headerScanRows && rowIdx < len(rows) && !nameCols.found(); rowIdx++ {
+		titleCol, pdhCol, signInCol, signOutCol = -1, -1, -1, -1
+		headerRow = rowIdx
+		nameCols = findNameColumns(rows[rowIdx])
+		for i, cell := range rows[rowIdx] {
+			if nameCols.has(i) {
+				continue
+			}
+			if titleCol == -1 && isTitleHeader(cell) {
+				titleCol = i
+			} else if pdhCol == -1 && isPDHHeader(cell) {
+				pdhCol = i
+			} else if signInCol == -1 && isSignInHeader(cell) {
+				signInCol = i
+			} else if signOutCol == -1 && isSignOutHeader(cell) {
+				signOutCol = i
+			}
+		}
+	}
+
+	if !nameCols.found() {
+		return nil, missingColumns(filepath, rows, headerScanRows, "Name column not found")
+	}
+
+	// Read attendee names (skip header row)
+	for i := headerRow + 1; i < len(rows); i++ {
+		if name, joined := nameCols.name(rows[i]); name != "" {
+			if !joined {
+				name = ConvertNameFormat(name)
+			}
+			attendee := Attendee{Name: name, Email: "", PDH: DefaultPDH}
+			if titleCol != -1 && len(rows[i]) > titleCol {
+				attendee.Title = strings.TrimSpace(rows[i][titleCol])
+			}
+			if signInCol != -1 && signOutCol != -1 {
+				var signIn, signOut string
+				if len(rows[i]) > signInCol {
+					signIn = rows[i][signInCol]
+				}
+				if len(rows[i]) > signOutCol {
+					signOut = rows[i][signOutCol]
+				}
+				if signIn == "" || signOut == "" {
+					log.Printf("Warning: %s is missing sign-in or sign-out time; using %g PDH", opts.PII.Name(name), DefaultPDH)
+				} else if hours, err := attendedHours(signIn, signOut); err != nil {
+					log.Printf("Warning: %s: %v; using %g PDH", opts.PII.Name(name), err, DefaultPDH)
+				} else {
+					attendee.PDH = hours
+				}
+			}
+			if pdhCol != -1 && len(rows[i]) > pdhCol && strings.TrimSpace(rows[i][pdhCol]) != "" {
+				if hours, err := parsePDH(rows[i][pdhCol]); err != nil {
+					log.Printf("Warning: %s: %v; using %g PDH", opts.PII.Name(name), err, attendee.PDH)
+				} else {
+					attendee.PDH = hours
+				}
+			}
+			// Kept, so the caller decides whether it earns a certificate
+			if attendee.PDH <= 0 {
+				log.Printf("Warning: %s earned %g PDH", opts.PII.Name(name), attendee.PDH)
+			}
+			attendees = append(attendees, attendee)
+		}
+	}
+
+	return attendees, nil
+}
+
+// nameSuffixes are trailing words that aren't part of a surname.
+var nameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	"pe": true, "p.e": true, "phd": true, "ph.d": true, "se": true, "s.e": true,
+}
+
+// SplitName splits a "First Last" name into first and last name, ignoring
+// credentials after a comma ("John Smith, PE") and generational or
+// credential suffixes ("John Smith Jr.").
+func SplitName(name string) (first, last string) {
+	if idx := strings.Index(name, ","); idx != -1 {
+		name = name[:idx]
+	}
+	fields := strings.Fields(name)
+	for len(fields) > 1 && nameSuffixes[strings.ToLower(strings.TrimSuffix(fields[len(fields)-1], "."))] {
+		fields = fields[:len(fields)-1]
+	}
+
+	switch len(fields) {
+	case 0:
+		return "", ""
+	case 1:
+		return fields[0], ""
+	}
+	return fields[0], fields[len(fields)-1]
+}
+
+// SortAttendees orders attendees in place by "first" or "last" name. "sheet"
+// keeps spreadsheet order. Ties fall back to the other name part.
+func SortAttendees(attendees []Attendee, by string) {
+	if by == "sheet" {
+		return
+	}
+	sort.SliceStable(attendees, func(i, j int) bool {
+		first1, last1 := SplitName(attendees[i].Name)
+		first2, last2 := SplitName(attendees[j].Name)
+		key1 := strings.ToLower(first1 + "\x00" + last1)
+		key2 := strings.ToLower(first2 + "\x00" + last2)
+		if by == "last" {
+			key1 = strings.ToLower(last1 + "\x00" + first1)
+			key2 = strings.ToLower(last2 + "\x00" + first2)
+		}
+		return key1 < key2
+	})
+}
+
+// NameFormat says how names in a spreadsheet column are written.
+type NameFormat string
+
+const (
+	// NameAuto treats a name as "Last, First" unless what follows the first
+	// comma is a credential, as in "John Smith, PE"
+	NameAuto      NameFormat = "auto"
+	NameFirstLast NameFormat = "first-last"
+	NameLastFirst NameFormat = "last-first"
+)
+
+// NameFormats lists the accepted NameFormat values.
+func NameFormats() []string {
+	return []string{string(NameAuto), string(NameFirstLast), string(NameLastFirst)}
+}
+
+// credentialWords are comma-separated name parts that are credentials or
+// generational suffixes rather than a first name. Dots are ignored.
+var credentialWords = map[string]bool{
+	"pe": true, "se": true, "phd": true, "jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	"eit": true, "ei": true, "pls": true, "ms": true, "mba": true, "pmp": true, "cfm": true, "ptoe": true,
+	"leed ap": true, "aia": true, "ret": true,
+}
+
+// generationalSuffixes are the credentialWords that belong with a surname,
+// as in "Smith, Jr., John".
+var generationalSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+}
+
+// nameKey is a comma-separated name part as looked up in credentialWords.
+func nameKey(part string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(part, ".", "")))
+}
+
+// isFirstLast reports whether a comma-separated name is already "First Last,
+// Credentials": the part after the first comma is a known credential, or a
+// short all-caps abbreviation such as "CPESC" following a full name. A lone
+// word before the comma is always a surname, so "SMITH, JOHN" still swaps.
+func isFirstLast(parts []string) bool {
+	next := strings.TrimSpace(strings.ReplaceAll(parts[1], ".", ""))
+	if credentialWords[strings.ToLower(next)] {
+		return true
+	}
+	abbreviation := len(next) >= 2 && len(next) <= 6 && !strings.Contains(next, " ") &&
+		next == strings.ToUpper(next) && strings.ToLower(next) != next
+	return abbreviation && len(strings.Fields(parts[0])) >= 2
+}
+
+// ConvertNameFormat turns a "Last, First" name into "First Last", leaving
+// names already in "First Last" form alone, so applying it twice is the same
+// as applying it once.
+func ConvertNameFormat(name string) string {
+	return ConvertNameFormatAs(name, NameAuto)
+}
+
+// ConvertNameFormatAs is ConvertNameFormat with the input format given
+// rather than guessed. NameLastFirst always swaps around the first comma.
+func ConvertNameFormatAs(name string, format NameFormat) string {
+	// Convert from "Last, First" to "First Last". Any further comma-separated
+	// fields are credentials, so "Smith, John, PE" becomes "John Smith, PE".
+	parts := strings.Split(name, ",")
+	// A generational suffix between surname and first name stays with the
+	// surname, so "Smith, Jr., John" becomes "John Smith Jr."
+	if len(parts) >= 3 && format != NameFirstLast && generationalSuffixes[nameKey(parts[1])] && !credentialWords[nameKey(parts[2])] {
+		parts = append([]string{strings.TrimSpace(parts[0]) + " " + strings.TrimSpace(parts[1])}, parts[2:]...)
+	}
+	lastFirst := format == NameLastFirst || (format != NameFirstLast && len(parts) >= 2 && !isFirstLast(parts))
+	if len(parts) >= 2 && lastFirst {
+		first := strings.TrimSpace(parts[1])
+		last := strings.TrimSpace(parts[0])
+		converted := first + " " + last
+		for _, credential := range parts[2:] {
+			if credential = strings.TrimSpace(credential); credential != "" {
+				converted += ", " + credential
+			}
+		}
+		return converted
+	}
+	return strings.TrimSpace(name)
+}
+
+// ReadRoster maps each member's name, in "First Last" form, to their email,
+// credentials, and any other columns. format says how the roster writes
+// names; separate First Name and Last Name columns are joined as written. It
+// logs a warning for names listed with more than one email.
+func ReadRoster(filepath string, format NameFormat, opts ReadOptions) (map[string]RosterEntry, error) {
+	rows, err := ReadRows(filepath, "roster", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nameToEmail := make(map[string]RosterEntry)
+	nameCols := nameColumns{full: -1, first: -1, last: -1}
+	emailCol, titleCol := -1, -1
+	headerRow := 0
+
+	// Find the name and Email columns, skipping any banner rows above them
+	for rowIdx := 0; rowIdx < headerScanRows && rowIdx < len(rows) && (!nameCols.found() || emailCol == -1); rowIdx++ {
+		emailCol, titleCol = -1, -1
+		headerRow = rowIdx
+		nameCols = findNameColumns(rows[rowIdx])
+		for i, cell := range rows[rowIdx] {
+			if nameCols.has(i) {
+				continue
+			}
+			if emailCol == -1 && HeaderHas(cell, "email") {
+				emailCol = i
+			} else if titleCol == -1 && isTitleHeader(cell) {
+				titleCol = i
+			}
+		}
+	}
+
+	if !nameCols.found() || emailCol == -1 {
+		return nil, missingColumns(filepath, rows, headerScanRows, "Name or Email column not found in roster")
+	}
+
+	// Every other labeled column is passed through for templates
+	extraCols := make(map[int]string)
+	for i, cell := range rows[headerRow] {
+		if key := FieldKey(cell); key != "" && !nameCols.has(i) && i != emailCol && i != titleCol {
+			extraCols[i] = key
+		}
+	}
+
+	// Read name-email mappings (skip header row). Track every email seen per
+	// normalized name, since the map below keeps only the last one.
+	emailsByName := make(map[string][]string)
+	var displayNames []string
+	for i := headerRow + 1; i < len(rows); i++ {
+		if len(rows[i]) > emailCol {
+			name, joined := nameCols.name(rows[i])
+			email, emailName := parseRosterEmail(rows[i][emailCol])
+			if name != "" && email != "" {
+				// Convert name to match attendance format
+				if !joined {
+					name = ConvertNameFormatAs(name, format)
+				}
+				entry := RosterEntry{Email: email, EmailName: emailName, Fields: make(map[string]string)}
+				if titleCol != -1 && len(rows[i]) > titleCol {
+					entry.Title = strings.TrimSpace(rows[i][titleCol])
+				}
+				for col, key := range extraCols {
+					if col < len(rows[i]) {
+						entry.Fields[key] = strings.TrimSpace(rows[i][col])
+					}
+				}
+				nameToEmail[name] = entry
+
+				key := strings.ToLower(strings.Join(strings.Fields(name), " "))
+				if _, seen := emailsByName[key]; !seen {
+					displayNames = append(displayNames, name)
+				}
+				if !containsFold(emailsByName[key], email) {
+					emailsByName[key] = append(emailsByName[key], email)
+				}
+			}
+		}
+	}
+
+	// Warn about duplicate names with different emails so the data owner can
+	// disambiguate them (e.g. with middle initials)
+	for _, name := range displayNames {
+		emails := emailsByName[strings.ToLower(strings.Join(strings.Fields(name), " "))]
+		if len(emails) > 1 {
+			masked := make([]string, len(emails))
+			for i, email := range emails {
+				masked[i] = opts.PII.Email(email)
+			}
+			log.Printf("Warning: roster has %d entries named %s with different emails: %s",
+				len(emails), opts.PII.Name(name), strings.Join(masked, ", "))
+		}
+	}
+
+	return nameToEmail, nil
+}
+
+// parseRosterEmail splits an email cell like "John Smith <john@x.com>"
+// into the bare address and display name. Bare addresses, and cells that
+// don't parse, are returned as they are with no name.
+func parseRosterEmail(cell string) (email, name string) {
+	cell = strings.TrimSpace(cell)
+	if !strings.Contains(cell, "<") {
+		return cell, ""
+	}
+	addr, err := mail.ParseAddress(cell)
+	if err != nil {
+		return cell, ""
+	}
+	return addr.Address, addr.Name
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAttendeesWithEmails fills in each attendee's email, extra roster
+// fields, and, if the attendance sheet had none, credentials from the roster.
+func MatchAttendeesWithEmails(attendees []Attendee, roster map[string]RosterEntry) []Attendee {
+	for i, attendee := range attendees {
+		if entry, found := roster[attendee.Name]; found {
+			attendees[i].Email = entry.Email
+			attendees[i].EmailName = entry.EmailName
+			attendees[i].Fields = entry.Fields
+			// Credentials on the sign-in sheet take precedence over the roster
+			if attendees[i].Title == "" {
+				attendees[i].Title = entry.Title
+			}
+		}
+	}
+	return attendees
+}
+
+// FindUnmatchedAttendees returns attendees whose email is missing or unparseable.
+func FindUnmatchedAttendees(attendees []Attendee) []Attendee {
+	var unmatched []Attendee
+	for _, attendee := range attendees {
+		if _, err := mail.ParseAddress(attendee.Email); err != nil {
+			unmatched = append(unmatched, attendee)
+		}
+	}
+	return unmatched
+}
+
+// WithoutAttendees returns attendees minus anyone named in remove.
+func WithoutAttendees(attendees []Attendee, remove []Attendee) []Attendee {
+	skip := make(map[string]bool)
+	for _, attendee := range remove {
+		skip[attendee.Name] = true
+	}
+
+	var kept []Attendee
+	for _, attendee := range attendees {
+		if !skip[attendee.Name] {
+			kept = append(kept, attendee)
+		}
+	}
+	return kept
+}
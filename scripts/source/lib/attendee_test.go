@@ -0,0 +1,86 @@
+package lib
+
+import "testing"
+
+func TestConvertNameFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		// No comma
+		{"John Smith", "John Smith"},
+		{"  John Smith ", "John Smith"},
+		{"John Smith Jr.", "John Smith Jr."},
+		// One comma
+		{"Smith, John", "John Smith"},
+		{"SMITH, JOHN", "JOHN SMITH"},
+		{"Smith,John", "John Smith"},
+		{"John Smith, PE", "John Smith, PE"},
+		{"John Smith, P.E.", "John Smith, P.E."},
+		{"John Smith, Jr.", "John Smith, Jr."},
+		{"John Smith, CPESC", "John Smith, CPESC"},
+		// Two or more commas
+		{"Smith, John, PE", "John Smith, PE"},
+		{"Smith, John, PE, PhD", "John Smith, PE, PhD"},
+		{"Smith, Jr., John", "John Smith Jr."},
+		{"Smith, Jr., John, PE", "John Smith Jr., PE"},
+		{"John Smith, Jr., PE", "John Smith, Jr., PE"},
+	}
+	for _, tt := range tests {
+		got := ConvertNameFormat(tt.name)
+		if got != tt.want {
+			t.Errorf("ConvertNameFormat(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if again := ConvertNameFormat(got); again != got {
+			t.Errorf("ConvertNameFormat(%q) = %q, not idempotent on %q", got, again, tt.name)
+		}
+	}
+}
+
+func TestConvertNameFormatAs(t *testing.T) {
+	tests := []struct {
+		name   string
+		format NameFormat
+		want   string
+	}{
+		{"Smith, John", NameFirstLast, "Smith, John"},
+		{"Smith, Jr., John", NameFirstLast, "Smith, Jr., John"},
+		{"John Smith", NameLastFirst, "John Smith"},
+		{"Smith, John", NameLastFirst, "John Smith"},
+		// A roster known to be "Last, First" swaps even what looks like a
+		// credential
+		{"Smith, PE", NameLastFirst, "PE Smith"},
+		{"Smith, John, PE", NameLastFirst, "John Smith, PE"},
+		{"Smith, Jr., John", NameLastFirst, "John Smith Jr."},
+	}
+	for _, tt := range tests {
+		if got := ConvertNameFormatAs(tt.name, tt.format); got != tt.want {
+			t.Errorf("ConvertNameFormatAs(%q, %s) = %q, want %q", tt.name, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFirstName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Jane Smith", "Jane"},
+		{"Dr. Jane Smith", "Jane"},
+		{"Dr Jane Smith", "Jane"},
+		{"Dr. John Smith, PE", "John"},
+		{"Smith, John", "John"},
+		{"Smith, John, PE", "John"},
+		{"J. Robert Smith", "Robert"},
+		{"J Robert Smith", "Robert"},
+		// Too little left to tell a first name from
+		{"Dr. Smith", "Dr. Smith"},
+		{"Madonna", "Madonna"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := FirstName(tt.name); got != tt.want {
+			t.Errorf("FirstName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// gradientPrefix marks a CertificateConfig.Background that is a gradient
+// rather than an image path.
+const gradientPrefix = "gradient:"
+
+// Color is an RGB text color, each component 0-255.
+type Color struct {
+	R, G, B int
+}
+
+// ParseColor parses a hex color such as "#1a2b3c" (the "#" is optional).
+func ParseColor(s string) (Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return Color{}, fmt.Errorf("color %q must be #RRGGBB", s)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("color %q must be #RRGGBB", s)
+	}
+	return Color{R: int(value >> 16), G: int(value >> 8 & 0xff), B: int(value & 0xff)}, nil
+}
+
+// ValidateBackground checks a CertificateConfig.Background value before any
+// certificates are generated: a gradient must have two valid colors and an
+// image must be a readable PNG, JPEG, or GIF file.
+func ValidateBackground(background string) error {
+	if strings.HasPrefix(background, gradientPrefix) {
+		_, _, err := parseGradient(background)
+		return err
+	}
+	_, err := ImageType(background)
+	return err
+}
+
+// parseGradient splits "gradient:#RRGGBB,#RRGGBB" into its top and bottom
+// colors.
+func parseGradient(background string) (Color, Color, error) {
+	parts := strings.Split(strings.TrimPrefix(background, gradientPrefix), ",")
+	if len(parts) != 2 {
+		return Color{}, Color{}, fmt.Errorf("gradient %q must be gradient:#RRGGBB,#RRGGBB", background)
+	}
+	top, err := ParseColor(parts[0])
+	if err != nil {
+		return Color{}, Color{}, err
+	}
+	bottom, err := ParseColor(parts[1])
+	if err != nil {
+		return Color{}, Color{}, err
+	}
+	return top, bottom, nil
+}
+
+// drawBackground fills the current page edge to edge. It runs before the
+// design so every line of text lands on top of it.
+func drawBackground(pdf *gofpdf.Fpdf, background string) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	if strings.HasPrefix(background, gradientPrefix) {
+		top, bottom, err := parseGradient(background)
+		if err != nil {
+			pdf.SetError(err)
+			return
+		}
+		pdf.LinearGradient(0, 0, pageWidth, pageHeight, top.R, top.G, top.B, bottom.R, bottom.G, bottom.B, 0, 0, 0, 1)
+		return
+	}
+	// The image is stretched to the page, so it should be made at the page's
+	// aspect ratio (11:8.5 landscape)
+	imageType, err := ImageType(background)
+	if err != nil {
+		pdf.SetError(err)
+		return
+	}
+	pdf.ImageOptions(background, 0, 0, pageWidth, pageHeight, false, gofpdf.ImageOptions{ImageType: imageType}, 0, "")
+}
+
+// setTextColor applies a design's text color, unless config.TextColor
+// overrides every design color for contrast with a background.
+func setTextColor(pdf *gofpdf.Fpdf, config CertificateConfig, r, g, b int) {
+	if config.TextColor != nil {
+		r, g, b = config.TextColor.R, config.TextColor.G, config.TextColor.B
+	}
+	pdf.SetTextColor(r, g, b)
+}
+
+// setDrawColor makes rules (the name underline and signature line) follow
+// config.TextColor. Decorative colors in a design, like the banquet frame,
+// are left alone.
+func setDrawColor(pdf *gofpdf.Fpdf, config CertificateConfig) {
+	if config.TextColor != nil {
+		pdf.SetDrawColor(config.TextColor.R, config.TextColor.G, config.TextColor.B)
+	}
+}
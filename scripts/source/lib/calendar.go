@@ -0,0 +1,395 @@
+package lib
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventInfo is one meeting from the calendar.
+type EventInfo struct {
+	Date     string
+	Topic    string
+	Speaker  string
+	Location string
+	Time     string
+	// Abstract is the optional session description from an "abstract" column
+	Abstract string
+
+	// DisplayDate is Date rendered with -date-format for certificates and
+	// emails. Filenames keep using the raw Date.
+	DisplayDate string
+}
+
+// ReadCalendar returns every calendar row that has a date, topic, and speaker.
+// Dates are left as the raw spreadsheet strings. See ReadOptions.Strict.
+func ReadCalendar(filepath string, opts ReadOptions) ([]EventInfo, error) {
+	rows, err := ReadRows(filepath, "calendar", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find column indices - check first two rows for headers. Within a row
+	// the first match wins, so "Meeting Date" or "Speaker Name" work but a
+	// later "Date Added" doesn't replace the real column.
+	var dateCol, topicCol, speakerCol, locationCol, timeCol, abstractCol int
+	var whenCol int // a combined date and time column
+	headerRow := 0
+
+	for rowIdx := 0; rowIdx < 2 && rowIdx < len(rows); rowIdx++ {
+		dateCol, topicCol, speakerCol, locationCol, timeCol, abstractCol = -1, -1, -1, -1, -1, -1
+		whenCol = -1
+		headerRow = rowIdx
+		for i, cell := range rows[rowIdx] {
+			if IsDateTimeHeader(cell) {
+				if whenCol == -1 {
+					whenCol = i
+				}
+			} else if dateCol == -1 && HeaderHas(cell, "date") {
+				dateCol = i
+			} else if topicCol == -1 && HeaderHas(cell, "topic") {
+				topicCol = i
+			} else if speakerCol == -1 && HeaderHas(cell, "speaker") {
+				speakerCol = i
+			} else if locationCol == -1 && HeaderHas(cell, "location") {
+				locationCol = i
+			} else if abstractCol == -1 && HeaderHas(cell, "abstract") {
+				abstractCol = i
+			} else if timeCol == -1 && HeaderHas(cell, "time") {
+				timeCol = i
+			}
+		}
+		if (dateCol != -1 || whenCol != -1) && topicCol != -1 && speakerCol != -1 {
+			break
+		}
+	}
+
+	// A separate date column wins; otherwise the date comes from the
+	// combined column. Its time fills in for a missing time column either
+	// way.
+	if dateCol == -1 {
+		dateCol = whenCol
+	}
+
+	if dateCol == -1 || topicCol == -1 || speakerCol == -1 {
+		return nil, missingColumns(filepath, rows, 2, "required columns (date, topic, speaker) not found")
+	}
+
+	// Collect the non-empty events
+	var events []EventInfo
+	for i := headerRow + 1; i < len(rows); i++ {
+		if len(rows[i]) > dateCol && rows[i][dateCol] != "" {
+			event := EventInfo{}
+			event.Date = rows[i][dateCol]
+
+			if len(rows[i]) > topicCol {
+				event.Topic = rows[i][topicCol]
+			}
+			if len(rows[i]) > speakerCol {
+				event.Speaker = rows[i][speakerCol]
+			}
+			if locationCol != -1 && len(rows[i]) > locationCol {
+				event.Location = rows[i][locationCol]
+			}
+			if timeCol != -1 && len(rows[i]) > timeCol {
+				event.Time = rows[i][timeCol]
+			}
+			if abstractCol != -1 && len(rows[i]) > abstractCol {
+				event.Abstract = rows[i][abstractCol]
+			}
+			if whenCol != -1 && len(rows[i]) > whenCol {
+				whenDate, clock := SplitDateTime(rows[i][whenCol])
+				if dateCol == whenCol {
+					event.Date = whenDate
+				}
+				if event.Time == "" {
+					event.Time = clock
+				}
+			}
+
+			if opts.Strict {
+				if event.Topic == "" || event.Speaker == "" {
+					return nil, &ParseError{File: filepath, Row: i + 1, Detail: "date but no topic or speaker"}
+				}
+				if _, err := ParseFlexibleDate(event.Date); err != nil {
+					return nil, &ParseError{File: filepath, Row: i + 1, Detail: err.Error(), Err: err}
+				}
+			}
+
+			if event.Topic != "" && event.Speaker != "" {
+				events = append(events, event)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// ReadCalendars reads and merges several calendars, such as the club's own
+// and one for joint meetings. An event in more than one file (same date and
+// topic) is kept once, from the first file listed, with a warning.
+func ReadCalendars(paths []string, opts ReadOptions) ([]EventInfo, error) {
+	var merged []EventInfo
+	seen := make(map[string]string) // event key -> file it came from
+	for _, path := range paths {
+		events, err := ReadCalendar(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			key := eventKey(event)
+			if first, ok := seen[key]; ok {
+				log.Printf("Warning: %q on %s is in both %s and %s; using the one from %s", event.Topic, event.Date, first, path, first)
+				continue
+			}
+			seen[key] = path
+			merged = append(merged, event)
+		}
+	}
+	return merged, nil
+}
+
+// eventKey identifies an event across calendars by its date, parsed so
+// "09/10/2025" and "2025-09-10" agree, and its topic ignoring case and
+// spacing.
+func eventKey(event EventInfo) string {
+	date := event.Date
+	if t, err := ParseFlexibleDate(date); err == nil {
+		date = t.Format("2006-01-02")
+	}
+	return date + "|" + strings.ToLower(strings.Join(strings.Fields(event.Topic), " "))
+}
+
+// MostRecentEvent returns the latest event across the calendars that has
+// already happened. When several share that date, eventTopic picks one; if
+// it is empty the first by topic is used and a warning is logged. A
+// positive lookbackDays only considers events from that many days back, so
+// a stale or mis-dated row can't be chosen; it is an error if none qualify.
+// With opts.Strict, a calendar with no past events is an error too.
+func MostRecentEvent(calendars []string, eventTopic string, lookbackDays int, opts ReadOptions) (EventInfo, error) {
+	events, err := ReadCalendars(calendars, opts)
+	if err != nil {
+		return EventInfo{}, err
+	}
+	return SelectMostRecentEvent(events, eventTopic, lookbackDays, opts.Strict)
+}
+
+// SelectMostRecentEvent is MostRecentEvent for events already read. Unless
+// strict, it falls back to the whole calendar when no event is past.
+func SelectMostRecentEvent(events []EventInfo, eventTopic string, lookbackDays int, strict bool) (EventInfo, error) {
+	if len(events) == 0 {
+		return EventInfo{}, fmt.Errorf("%w in the calendar", ErrNoEvents)
+	}
+
+	// Filter events to only include past events and sort by date to get most recent past event
+	now := time.Now()
+	pastEvents := PastEvents(events, now)
+
+	if lookbackDays > 0 {
+		cutoff := now.AddDate(0, 0, -lookbackDays)
+		var recent []EventInfo
+		for _, event := range pastEvents {
+			if eventDate, err := ParseFlexibleDate(event.Date); err == nil && !eventDate.Before(cutoff) {
+				recent = append(recent, event)
+			}
+		}
+		if len(recent) == 0 {
+			if len(pastEvents) == 0 {
+				return EventInfo{}, fmt.Errorf("%w in the last %d days", ErrNoEvents, lookbackDays)
+			}
+			SortNewestFirst(pastEvents)
+			return EventInfo{}, fmt.Errorf("%w in the last %d days; the most recent is %q on %s", ErrNoEvents, lookbackDays, pastEvents[0].Topic, pastEvents[0].Date)
+		}
+		pastEvents = recent
+	}
+
+	// If no past events, use all events (fallback)
+	if len(pastEvents) == 0 && strict {
+		return EventInfo{}, fmt.Errorf("%w in the calendar have already happened", ErrNoEvents)
+	}
+	if len(pastEvents) == 0 {
+		pastEvents = events
+	}
+
+	SortNewestFirst(pastEvents)
+
+	// Detect multiple events sharing the most recent date
+	var tied []EventInfo
+	for _, event := range pastEvents {
+		if sameEventDate(event, pastEvents[0]) {
+			tied = append(tied, event)
+		}
+	}
+
+	if len(tied) > 1 {
+		if eventTopic != "" {
+			for _, event := range tied {
+				if strings.EqualFold(strings.TrimSpace(event.Topic), strings.TrimSpace(eventTopic)) {
+					return event, nil
+				}
+			}
+			return EventInfo{}, fmt.Errorf("no event on %s has topic %q", pastEvents[0].Date, eventTopic)
+		}
+		log.Printf("Warning: %d events share the most recent date %s; using %q. Pass -event-topic to choose another.",
+			len(tied), pastEvents[0].Date, pastEvents[0].Topic)
+	}
+
+	return pastEvents[0], nil
+}
+
+// PastEvents returns the events dated before now. Events with unparseable
+// dates are left out.
+func PastEvents(events []EventInfo, now time.Time) []EventInfo {
+	var past []EventInfo
+	for _, event := range events {
+		eventDate, err := ParseFlexibleDate(event.Date)
+		if err == nil && eventDate.Before(now) {
+			past = append(past, event)
+		}
+	}
+	return past
+}
+
+// SortNewestFirst orders events by date, most recent first. Events on the
+// same date are ordered by topic so the selection is deterministic.
+func SortNewestFirst(events []EventInfo) {
+	sort.Slice(events, func(i, j int) bool {
+		// Try to parse dates
+		date1, err1 := ParseFlexibleDate(events[i].Date)
+		date2, err2 := ParseFlexibleDate(events[j].Date)
+
+		if err1 == nil && err2 == nil {
+			if date1.Equal(date2) {
+				return events[i].Topic < events[j].Topic
+			}
+			return date1.After(date2)
+		}
+		// If parsing fails, do string comparison
+		if events[i].Date == events[j].Date {
+			return events[i].Topic < events[j].Topic
+		}
+		return events[i].Date > events[j].Date
+	})
+}
+
+func sameEventDate(a, b EventInfo) bool {
+	date1, err1 := ParseFlexibleDate(a.Date)
+	date2, err2 := ParseFlexibleDate(b.Date)
+	if err1 == nil && err2 == nil {
+		return date1.Equal(date2)
+	}
+	return a.Date == b.Date
+}
+
+// ParseFlexibleDate parses the date formats seen in club spreadsheets,
+// including the day numbers Excel shows for a date cell without a date
+// format.
+func ParseFlexibleDate(dateStr string) (time.Time, error) {
+	formats := []string{
+		"01/02/2006",
+		"1/2/2006",
+		"1/02/2006",
+		"01/2/2006",
+		"2006-01-02",
+		"2006/01/02",
+		"January 2, 2006",
+		"Jan 2, 2006",
+		"2 January 2006",
+		"2 Jan 2006",
+		"02-Jan-2006",
+		"2-Jan-2006",
+		time.RFC3339,
+	}
+
+	for _, format := range formats {
+		if t, err := time.Parse(format, dateStr); err == nil {
+			return t, nil
+		}
+	}
+
+	// Excel serial dates. Only accept serials in a plausible range so a stray
+	// number like "2025" in the date column isn't read as a day in 1905.
+	if days, err := strconv.ParseFloat(dateStr, 64); err == nil && days > 0 {
+		excelEpoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+		t := excelEpoch.AddDate(0, 0, int(days))
+		if t.Year() < 2000 || t.Year() > 2100 {
+			return time.Time{}, fmt.Errorf("%w: number %s is outside the Excel date range for 2000-2100", ErrUnparseableDate, dateStr)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s", ErrUnparseableDate, dateStr)
+}
+
+// IsDateTimeHeader reports whether a column header names a combined date
+// and time column: "datetime", "date/time", "Date & Time", or "when".
+func IsDateTimeHeader(header string) bool {
+	var letters strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if r >= 'a' && r <= 'z' {
+			letters.WriteRune(r)
+		}
+	}
+	switch letters.String() {
+	case "datetime", "when":
+		return true
+	}
+	return false
+}
+
+// SplitDateTime splits a combined value such as "2025-03-14 11:30 AM" or
+// "March 14, 2025 6 PM" into its date and time of day. The time starts at
+// the first word after the date that looks like a clock reading; a value
+// with no time is returned whole as the date.
+func SplitDateTime(value string) (date string, clock string) {
+	value = strings.TrimSpace(value)
+	// ISO 8601, as in "2025-03-14T11:30"
+	if len(value) > 11 && value[10] == 'T' {
+		if _, err := time.Parse("2006-01-02", value[:10]); err == nil {
+			return value[:10], value[11:]
+		}
+	}
+	fields := strings.Fields(value)
+	for k := 1; k < len(fields); k++ {
+		if startsClock(fields, k) {
+			return strings.Join(fields[:k], " "), strings.Join(fields[k:], " ")
+		}
+	}
+	return value, ""
+}
+
+// startsClock reports whether fields[k] begins a time of day: a number
+// with a colon ("11:30"), an hour with am/pm attached ("6pm"), or an hour
+// followed by a separate am/pm ("6 PM").
+func startsClock(fields []string, k int) bool {
+	word := strings.ToLower(fields[k])
+	if word[0] < '0' || word[0] > '9' {
+		return false
+	}
+	if strings.Contains(word, ":") {
+		return true
+	}
+	for _, suffix := range []string{"am", "pm", "a.m.", "p.m."} {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+		if k+1 < len(fields) && strings.ToLower(fields[k+1]) == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatEventDate renders a spreadsheet date with the given layout, with
+// month and weekday names in the certificate language, falling back to the
+// raw string if it can't be parsed.
+func FormatEventDate(date string, layout string, lang string) string {
+	t, err := ParseFlexibleDate(date)
+	if err != nil {
+		return date
+	}
+	return textFor(lang).localizeDate(t.Format(layout))
+}
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+)
+
+// sameDayEvents are two meetings on the most recent date, listed out of
+// topic order, and an older one.
+var sameDayEvents = []EventInfo{
+	{Date: "2020-03-11", Topic: "Stormwater Design", Speaker: "Pat Jones"},
+	{Date: "2020-02-12", Topic: "Levee Safety", Speaker: "Lee Park"},
+	{Date: "03/11/2020", Topic: "Bridge Inspection", Speaker: "Jane Doe"},
+}
+
+func TestSelectMostRecentEventTopic(t *testing.T) {
+	for _, topic := range []string{"Stormwater Design", " stormwater design "} {
+		event, err := SelectMostRecentEvent(append([]EventInfo(nil), sameDayEvents...), topic, 0, false)
+		if err != nil {
+			t.Fatalf("topic %q: %v", topic, err)
+		}
+		if event.Speaker != "Pat Jones" {
+			t.Errorf("topic %q picked %q, want Stormwater Design", topic, event.Topic)
+		}
+	}
+}
+
+func TestSelectMostRecentEventUnmatchedTopic(t *testing.T) {
+	// An older event's topic only counts among those on the latest date
+	for _, topic := range []string{"Geotechnical Basics", "Levee Safety"} {
+		if event, err := SelectMostRecentEvent(append([]EventInfo(nil), sameDayEvents...), topic, 0, false); err == nil {
+			t.Errorf("topic %q picked %q, want an error", topic, event.Topic)
+		}
+	}
+}
+
+func TestSelectMostRecentEventFirstByTopic(t *testing.T) {
+	reversed := []EventInfo{sameDayEvents[2], sameDayEvents[1], sameDayEvents[0]}
+	for _, events := range [][]EventInfo{sameDayEvents, reversed} {
+		event, err := SelectMostRecentEvent(append([]EventInfo(nil), events...), "", 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Topic != "Bridge Inspection" {
+			t.Errorf("picked %q, want Bridge Inspection, the first by topic", event.Topic)
+		}
+	}
+}
+
+func TestReadCalendarStrictRowError(t *testing.T) {
+	data := "Date,Topic,Speaker\n2020-03-11,Bridge Inspection,Jane Doe\nsoon,Levee Safety,Lee Park\n"
+	path := writeFile(t, "calendar.csv", []byte(data))
+	_, err := ReadCalendar(path, ReadOptions{Strict: true})
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("ReadCalendar error = %v, want a *ParseError", err)
+	}
+	if parseErr.File != path || parseErr.Row != 3 {
+		t.Errorf("error at %s row %d, want %s row 3", parseErr.File, parseErr.Row, path)
+	}
+	if !errors.Is(err, ErrUnparseableDate) {
+		t.Errorf("error %v doesn't wrap ErrUnparseableDate", err)
+	}
+}
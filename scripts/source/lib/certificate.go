@@ -0,0 +1,231 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// CertificateConfig controls how GenerateCertificate lays out a certificate.
+// Orientation is a gofpdf orientation, "L" or "P".
+type CertificateConfig struct {
+	Design        string
+	Orientation   string
+	SignaturePath string
+	Signatory     string
+	// AbstractPage adds a second page with the session abstract for events
+	// that have one
+	AbstractPage bool
+	// Lang selects the certificate wording; see Languages. Empty means "en".
+	Lang string
+	// Background is drawn across the first page before the design: a PNG or
+	// JPEG path, or "gradient:#RRGGBB,#RRGGBB" for a top-to-bottom fade.
+	// Empty leaves the page white.
+	Background string
+	// TextColor, if set, replaces the design's text and rule colors so they
+	// stay legible over Background
+	TextColor *Color
+	// LogoPath is the classic design's logo, a PNG, JPEG, or GIF. Empty means
+	// DefaultLogoPath.
+	LogoPath string
+	// LogoWidth is the classic design's logo width in mm; zero means 50. The
+	// header text moves right to clear it.
+	LogoWidth float64
+	// NoLogoText leaves out the club name header, for logos that include it
+	NoLogoText bool
+	// ProviderName, ProviderNumber, and AccreditationText make up the
+	// continuing education block some state boards require. It is drawn
+	// only when ProviderNumber is set.
+	ProviderName      string
+	ProviderNumber    string
+	AccreditationText string
+	// BoardName, if set, adds a line under the PDH statement naming the
+	// licensing board the certificate documents continuing education for
+	BoardName string
+	// FontFamily replaces the designs' Times: a core font name (see
+	// FontFamilies) or a .ttf file to embed. Empty means Times.
+	FontFamily string
+	// Number, if set, is a sequential certificate number such as
+	// LREC-2025-0001, printed in place of the hash-based ID
+	Number string
+	// Watermark, if set, is printed faintly and diagonally behind the design,
+	// e.g. "REISSUED" to mark a copy
+	Watermark string
+	// Note is an optional short line printed under the attendee's name, e.g.
+	// their chapter; callers render it per attendee
+	Note string
+	// Issued is the issue date printed by the signature and stored as the
+	// PDF's creation date. Zero means today. Fixing it makes the PDF
+	// byte-for-byte reproducible.
+	Issued time.Time
+}
+
+// DefaultLogoPath is the club skyline, the logo used when
+// CertificateConfig.LogoPath is empty. It is left out if the file is missing.
+const DefaultLogoPath = "../scripts/skyline.png"
+
+// GenerateCertificate renders one attendee's certificate with the configured
+// design and saves it in outputDir as COA_<Name>_<Date>.pdf, returning the
+// path.
+func GenerateCertificate(config CertificateConfig, attendee Attendee, event EventInfo, outputDir string) (string, error) {
+	// Create PDF on US Letter, landscape unless portrait was requested
+	pdf := gofpdf.New(config.Orientation, "mm", "Letter", "")
+	if config.Issued.IsZero() {
+		now := time.Now()
+		config.Issued = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	pdf.SetCreationDate(config.Issued)
+	pdf.SetModificationDate(config.Issued)
+	// Fonts and images are kept in maps; sorting them makes output stable
+	pdf.SetCatalogSort(true)
+	// Everything is placed at fixed positions on one page; without this,
+	// blocks near the bottom margin like the signature spill onto extra pages
+	pdf.SetAutoPageBreak(false, 0)
+	registerFont(pdf, config)
+	pdf.AddPage()
+
+	if config.Background != "" {
+		drawBackground(pdf, config.Background)
+	}
+	if config.Watermark != "" {
+		drawWatermark(pdf, config, config.Watermark)
+	}
+	setTextColor(pdf, config, 0, 0, 0)
+	setDrawColor(pdf, config)
+
+	// Lay out the page with the selected design; the signature block is shared
+	design := Designs[config.Design](config)
+	design.Render(pdf, attendee, event)
+
+	if config.ProviderNumber != "" {
+		drawAccreditation(pdf, config, design)
+	}
+	if config.SignaturePath != "" {
+		setDrawColor(pdf, config)
+		drawSignatureBlock(pdf, config)
+	}
+	drawCertificateID(pdf, CertificateID(attendee, event), config)
+
+	if config.AbstractPage && event.Abstract != "" {
+		drawAbstractPage(pdf, event, config)
+	}
+
+	filepath := filepath.Join(outputDir, CertificateFilename(attendee, event))
+
+	err := pdf.OutputFileAndClose(filepath)
+	if err != nil {
+		return "", err
+	}
+	return filepath, nil
+}
+
+// CertificateFilename is the name GenerateCertificate saves a certificate
+// under: COA_<Name>_<Date>.pdf.
+func CertificateFilename(attendee Attendee, event EventInfo) string {
+	cleanName := strings.ReplaceAll(attendee.Name, " ", "_")
+	cleanDate := strings.ReplaceAll(event.Date, "/", "-")
+	return fmt.Sprintf("COA_%s_%s.pdf", cleanName, cleanDate)
+}
+
+// drawSignatureBlock places the signature image above a ruled line in the
+// lower-right corner, with the signatory name and issue date printed below.
+// The block is anchored to the page corner so that in landscape it sits right
+// of the centered location/date line and in portrait it falls below it.
+func drawSignatureBlock(pdf *gofpdf.Fpdf, config CertificateConfig) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	blockWidth := 55.0
+	blockX := pageWidth - 74.4
+	lineY := pageHeight - 27.9
+
+	imageType, err := ImageType(config.SignaturePath)
+	if err != nil {
+		pdf.SetError(err)
+		return
+	}
+	options := gofpdf.ImageOptions{ImageType: imageType, ReadDpi: true}
+	imageInfo := pdf.RegisterImageOptions(config.SignaturePath, options)
+	if imageInfo != nil && imageInfo.Height() > 0 {
+		// Fit the signature into a 55x15mm box sitting just above the line
+		imgHeight := 15.0
+		imgWidth := imageInfo.Width() * imgHeight / imageInfo.Height()
+		if imgWidth > blockWidth {
+			imgWidth = blockWidth
+			imgHeight = imageInfo.Height() * imgWidth / imageInfo.Width()
+		}
+		imgX := blockX + (blockWidth-imgWidth)/2
+		pdf.ImageOptions(config.SignaturePath, imgX, lineY-1-imgHeight, imgWidth, imgHeight, false, options, 0, "")
+	}
+
+	pdf.Line(blockX, lineY, blockX+blockWidth, lineY)
+
+	tr := translator(pdf, config)
+	setFont(pdf, config, "", 12)
+	if config.Signatory != "" {
+		pdf.SetXY(blockX, lineY+1)
+		pdf.CellFormat(blockWidth, 6, tr(config.Signatory), "", 0, "C", false, 0, "")
+	}
+	pdf.SetXY(blockX, lineY+7)
+	text := textFor(config.Lang)
+	issued := text.localizeDate(config.Issued.Format(text.DateLayout))
+	pdf.CellFormat(blockWidth, 6, tr(text.DateIssued+issued), "", 0, "C", false, 0, "")
+}
+
+// CertificateID derives a stable ID from the attendee and event, so the same
+// person at the same event always gets the same ID across reruns.
+func CertificateID(attendee Attendee, event EventInfo) string {
+	key := strings.ToLower(strings.Join([]string{attendee.Name, event.Date, event.Topic}, "|"))
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("LREC-%X", sum[:5])
+}
+
+// drawCertificateID prints the ID, or the sequential Number when there is
+// one, in small grey type in the lower-left corner, inside the border of
+// every design.
+func drawCertificateID(pdf *gofpdf.Fpdf, id string, config CertificateConfig) {
+	_, pageHeight := pdf.GetPageSize()
+	label := textFor(config.Lang).CertificateID + id
+	if config.Number != "" {
+		label = textFor(config.Lang).CertificateNo + config.Number
+	}
+	pdf.SetFont("Helvetica", "", 8)
+	setTextColor(pdf, config, 120, 120, 120)
+	pdf.SetXY(20, pageHeight-24)
+	pdf.CellFormat(80, 4, pdf.UnicodeTranslatorFromDescriptor("")(label), "", 0, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// drawAbstractPage adds a plain page with the session details and the
+// abstract wrapped to the page width. A very long abstract continues onto
+// further pages.
+func drawAbstractPage(pdf *gofpdf.Fpdf, event EventInfo, config CertificateConfig) {
+	text := textFor(config.Lang)
+	tr := translator(pdf, config)
+	pageWidth, _ := pdf.GetPageSize()
+	margin := 25.0
+
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.AddPage()
+
+	setFont(pdf, config, "B", 24)
+	pdf.SetXY(margin, margin)
+	pdf.CellFormat(pageWidth-2*margin, 12, tr(text.AbstractTitle), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	setFont(pdf, config, "I", 18)
+	pdf.SetX(margin)
+	pdf.MultiCell(pageWidth-2*margin, 9, tr(event.Topic), "", "C", false)
+	setFont(pdf, config, "", 14)
+	pdf.SetX(margin)
+	pdf.MultiCell(pageWidth-2*margin, 7, tr(text.By+event.Speaker), "", "C", false)
+	pdf.SetX(margin)
+	pdf.MultiCell(pageWidth-2*margin, 7, tr(event.DisplayDate), "", "C", false)
+	pdf.Ln(8)
+
+	setFont(pdf, config, "", 12)
+	pdf.SetX(margin)
+	pdf.MultiCell(pageWidth-2*margin, 6, tr(event.Abstract), "", "J", false)
+}
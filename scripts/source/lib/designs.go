@@ -0,0 +1,274 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// CertificateTemplate lays out one certificate design on a fresh page.
+// Designs are registered in Designs and selected by CertificateConfig.Design.
+type CertificateTemplate interface {
+	Render(pdf *gofpdf.Fpdf, attendee Attendee, event EventInfo)
+}
+
+// Designs holds every certificate design by name. Another tool can add its
+// own design here before generating certificates.
+var Designs = map[string]func(CertificateConfig) CertificateTemplate{
+	"classic": func(config CertificateConfig) CertificateTemplate { return layoutDesign{config, classicLayout} },
+	"banquet": func(config CertificateConfig) CertificateTemplate { return layoutDesign{config, banquetLayout} },
+}
+
+// DesignNames returns the names in Designs, sorted.
+func DesignNames() []string {
+	var names []string
+	for name := range Designs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// landscapeHeight is the height of a US Letter page in landscape, in mm.
+const landscapeHeight = 215.9
+
+// designLayout describes a design as data, so GenerateCertificate and
+// GenerateHTMLCertificate draw it from the same positions.
+type designLayout struct {
+	// Frames are borders drawn from the outside in
+	Frames []designFrame
+	// Header puts the logo and club name in the top-left corner
+	Header bool
+	// Lines are the body, each centered across the page
+	Lines []designLine
+	// BoardTop is where the -board-name statement goes
+	BoardTop float64
+}
+
+// designFrame is a rectangle Inset mm in from every page edge.
+type designFrame struct {
+	Inset, Width float64
+	Color        Color
+}
+
+// designLine is one line of body text, printed in a cell Height mm tall at
+// Top. Tops were laid out for a landscape page; on taller pages every line
+// moves down so the body stays vertically centered.
+type designLine struct {
+	Top, Height float64
+	// Style and Size are as passed to setFont
+	Style string
+	Size  float64
+	Color Color
+	// Underline, if not zero, rules the text that far below Top, in
+	// UnderlineColor or else the rule color
+	Underline      float64
+	UnderlineColor *Color
+	// Text returns the line for one certificate; an empty line is left out
+	Text func(text certificateText, config CertificateConfig, attendee Attendee, event EventInfo) string
+}
+
+var (
+	black = Color{0, 0, 0}
+	navy  = Color{20, 40, 90}
+	gold  = Color{180, 140, 40}
+)
+
+func clubName(certificateText, CertificateConfig, Attendee, EventInfo) string {
+	return "LITTLE ROCK ENGINEERS CLUB"
+}
+
+func attendeeName(_ certificateText, _ CertificateConfig, attendee Attendee, _ EventInfo) string {
+	return attendee.DisplayName()
+}
+
+func attendeeNote(_ certificateText, config CertificateConfig, _ Attendee, _ EventInfo) string {
+	return config.Note
+}
+
+// classicLayout is the original regular-meeting certificate.
+var classicLayout = designLayout{
+	Header: true,
+	Lines: []designLine{
+		{Top: 55, Height: 15, Style: "B", Size: 36, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, _ EventInfo) string {
+			return text.Title
+		}},
+		{Top: 70, Height: 10, Size: 18, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, _ EventInfo) string {
+			return text.Certify
+		}},
+		{Top: 95, Height: 10, Style: "B", Size: 24, Color: black, Underline: 12, Text: attendeeName},
+		{Top: 109, Height: 7, Style: "I", Size: 12, Color: black, Text: attendeeNote},
+		{Top: 120, Height: 10, Size: 16, Color: black, Text: func(text certificateText, config CertificateConfig, attendee Attendee, _ EventInfo) string {
+			return fmt.Sprintf(text.Earned, PDHPhrase(attendee.PDH, config.Lang))
+		}},
+		{Top: 135, Height: 10, Size: 16, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, _ EventInfo) string {
+			return text.Presentation
+		}},
+		{Top: 150, Height: 10, Style: "I", Size: 18, Color: black, Text: func(_ certificateText, _ CertificateConfig, _ Attendee, event EventInfo) string {
+			return event.Speaker
+		}},
+		{Top: 165, Height: 10, Style: "I", Size: 18, Color: black, Text: func(_ certificateText, _ CertificateConfig, _ Attendee, event EventInfo) string {
+			return event.Topic
+		}},
+		{Top: 185, Height: 10, Size: 16, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, event EventInfo) string {
+			return fmt.Sprintf(text.Conducted, event.DisplayDate)
+		}},
+	},
+	BoardTop: 129.5,
+}
+
+// banquetLayout is a framed, more formal certificate for the annual awards
+// banquet, with a navy and gold double border.
+var banquetLayout = designLayout{
+	Frames: []designFrame{
+		{Inset: 10, Width: 2, Color: navy},
+		{Inset: 15, Width: 0.6, Color: gold},
+	},
+	Lines: []designLine{
+		{Top: 28, Height: 10, Style: "B", Size: 20, Color: navy, Text: clubName},
+		{Top: 40, Height: 8, Style: "I", Size: 16, Color: navy, Text: func(text certificateText, _ CertificateConfig, _ Attendee, _ EventInfo) string {
+			return text.Banquet
+		}},
+		{Top: 58, Height: 15, Style: "B", Size: 34, Color: navy, Text: func(text certificateText, _ CertificateConfig, _ Attendee, _ EventInfo) string {
+			return text.Title
+		}},
+		{Top: 80, Height: 10, Size: 16, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, _ EventInfo) string {
+			return text.PresentedTo
+		}},
+		{Top: 95, Height: 14, Style: "BI", Size: 30, Color: black, Underline: 16, UnderlineColor: &gold, Text: attendeeName},
+		{Top: 113, Height: 7, Style: "I", Size: 12, Color: black, Text: attendeeNote},
+		{Top: 122, Height: 8, Size: 15, Color: black, Text: func(text certificateText, config CertificateConfig, attendee Attendee, _ EventInfo) string {
+			return fmt.Sprintf(text.BanquetEarned, PDHPhrase(attendee.PDH, config.Lang))
+		}},
+		{Top: 138, Height: 9, Style: "I", Size: 17, Color: black, Text: func(_ certificateText, _ CertificateConfig, _ Attendee, event EventInfo) string {
+			return event.Topic
+		}},
+		{Top: 149, Height: 9, Style: "I", Size: 17, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, event EventInfo) string {
+			return text.By + event.Speaker
+		}},
+		{Top: 168, Height: 8, Size: 14, Color: black, Text: func(text certificateText, _ CertificateConfig, _ Attendee, event EventInfo) string {
+			return text.BanquetPlace + event.DisplayDate
+		}},
+	},
+	BoardTop: 130.5,
+}
+
+// layoutDesign draws a designLayout into a PDF.
+type layoutDesign struct {
+	config CertificateConfig
+	layout designLayout
+}
+
+// FrameInset places other blocks inside the innermost frame, or is zero for
+// an unframed design.
+func (d layoutDesign) FrameInset() float64 {
+	if len(d.layout.Frames) == 0 {
+		return 0
+	}
+	return d.layout.Frames[len(d.layout.Frames)-1].Inset
+}
+
+func (d layoutDesign) Render(pdf *gofpdf.Fpdf, attendee Attendee, event EventInfo) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	offsetY := (pageHeight - landscapeHeight) / 2
+	text := textFor(d.config.Lang)
+	tr := translator(pdf, d.config)
+
+	for _, frame := range d.layout.Frames {
+		pdf.SetLineWidth(frame.Width)
+		pdf.SetDrawColor(frame.Color.R, frame.Color.G, frame.Color.B)
+		pdf.Rect(frame.Inset, frame.Inset, pageWidth-2*frame.Inset, pageHeight-2*frame.Inset, "D")
+	}
+	pdf.SetLineWidth(0.2)
+	pdf.SetDrawColor(0, 0, 0)
+	setDrawColor(pdf, d.config)
+
+	if d.layout.Header {
+		drawHeader(pdf, d.config)
+	}
+
+	for _, line := range d.layout.Lines {
+		s := line.Text(text, d.config, attendee, event)
+		if s == "" {
+			continue
+		}
+		s = tr(s)
+		top := line.Top + offsetY
+		setTextColor(pdf, d.config, line.Color.R, line.Color.G, line.Color.B)
+		setFont(pdf, d.config, line.Style, line.Size)
+		pdf.SetXY(0, top)
+		pdf.CellFormat(pageWidth, line.Height, s, "", 0, "C", false, 0, "")
+		if line.Underline != 0 {
+			if line.UnderlineColor != nil {
+				pdf.SetDrawColor(line.UnderlineColor.R, line.UnderlineColor.G, line.UnderlineColor.B)
+			}
+			width := pdf.GetStringWidth(s)
+			pdf.Line((pageWidth-width)/2, top+line.Underline, (pageWidth+width)/2, top+line.Underline)
+			pdf.SetDrawColor(0, 0, 0)
+			setDrawColor(pdf, d.config)
+		}
+	}
+
+	setTextColor(pdf, d.config, 0, 0, 0)
+	drawBoardStatement(pdf, d.config, d.layout.BoardTop+offsetY)
+}
+
+// drawHeader places the logo, the club skyline by default, in the top-left
+// corner with the club name beside it. The header sits at a fixed height
+// above the centered body.
+func drawHeader(pdf *gofpdf.Fpdf, config CertificateConfig) {
+	pageWidth, _ := pdf.GetPageSize()
+	skylinePath := config.LogoPath
+	if skylinePath == "" {
+		skylinePath = DefaultLogoPath
+	}
+	logoWidth := config.LogoWidth
+	if logoWidth == 0 {
+		logoWidth = 50
+	}
+	headerX := 25.0
+	if imageType, err := ImageType(skylinePath); err == nil {
+		options := gofpdf.ImageOptions{ImageType: imageType, ReadDpi: true}
+		if imageInfo := pdf.RegisterImageOptions(skylinePath, options); imageInfo != nil {
+			pdf.ImageOptions(skylinePath, 25, 15, logoWidth, 0, false, options, 0, "")
+			headerX += logoWidth + 5
+		}
+	}
+
+	// Same size as the classic name, shrunk if it would run off a narrow
+	// (portrait) page
+	if !config.NoLogoText {
+		headerText := "LITTLE ROCK ENGINEERS CLUB"
+		headerSize := 24.0
+		setTextColor(pdf, config, 0, 0, 0)
+		setFont(pdf, config, "B", headerSize)
+		for pdf.GetStringWidth(headerText) > pageWidth-headerX-15 && headerSize > 12 {
+			headerSize--
+			setFont(pdf, config, "B", headerSize)
+		}
+		pdf.SetXY(headerX, 25)
+		pdf.Cell(0, 10, headerText)
+	}
+}
+
+// drawBoardStatement prints the -board-name line centered at y, in small
+// italics under the PDH statement, shrinking the type so a long board name
+// stays clear of the page edges and the banquet frame. Nothing is drawn
+// without a board.
+func drawBoardStatement(pdf *gofpdf.Fpdf, config CertificateConfig, y float64) {
+	if config.BoardName == "" {
+		return
+	}
+	pageWidth, _ := pdf.GetPageSize()
+	statement := translator(pdf, config)(fmt.Sprintf(textFor(config.Lang).Board, strings.TrimSpace(config.BoardName)))
+	size := 11.0
+	setFont(pdf, config, "I", size)
+	for pdf.GetStringWidth(statement) > pageWidth-40 && size > 7 {
+		size -= 0.5
+		setFont(pdf, config, "I", size)
+	}
+	pdf.SetXY(0, y)
+	pdf.CellFormat(pageWidth, 5, statement, "", 0, "C", false, 0, "")
+}
@@ -0,0 +1,8 @@
+// Package lib holds the Little Rock Engineers Club certificate logic shared
+// by the club's tools: reading the roster, attendance, and calendar
+// spreadsheets, matching attendees to roster emails, choosing the most
+// recent event, and rendering certificates as PDFs or printable HTML pages.
+//
+// Readers log warnings about questionable data with the standard log
+// package, masking names and emails according to ReadOptions.PII.
+package lib
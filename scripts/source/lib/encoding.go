@@ -0,0 +1,91 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSVEncodings lists the character encodings CSV input may be in: "utf-8"
+// (the default), "latin-1", and "windows-1252", the "ANSI" encoding Excel
+// uses on Windows. Tools take one from their -encoding flag. Workbooks are
+// always UTF-8 internally and need none.
+func CSVEncodings() []string {
+	return []string{"utf-8", "latin-1", "windows-1252"}
+}
+
+// canonicalEncoding maps the spellings people use ("UTF8", "ISO-8859-1",
+// "cp1252") to a name in CSVEncodings, or "" if it is not supported.
+func canonicalEncoding(name string) string {
+	key := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(name))
+	switch key {
+	case "", "utf8":
+		return "utf-8"
+	case "latin1", "iso88591":
+		return "latin-1"
+	case "windows1252", "cp1252":
+		return "windows-1252"
+	}
+	return ""
+}
+
+// ValidateEncoding checks that an -encoding value is supported.
+func ValidateEncoding(name string) error {
+	if canonicalEncoding(name) == "" {
+		return fmt.Errorf("unsupported encoding %q: must be one of %s", name, strings.Join(CSVEncodings(), ", "))
+	}
+	return nil
+}
+
+// ReadCSVText returns a CSV file's contents as UTF-8, decoded from
+// encoding, one of CSVEncodings; empty means UTF-8. A leading UTF-8 byte
+// order mark, which Excel writes and which would otherwise stick to the
+// first header, is dropped. UTF-8 input that doesn't decode cleanly is read
+// anyway with a warning, since a wrong encoding usually only garbles
+// accented names.
+func ReadCSVText(path string, encoding string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	switch canonicalEncoding(encoding) {
+	case "latin-1":
+		return decodeSingleByte(data, nil), nil
+	case "windows-1252":
+		return decodeSingleByte(data, &windows1252), nil
+	case "utf-8":
+		if !utf8.Valid(data) {
+			log.Printf("Warning: %s is not valid UTF-8; if names look garbled, pass -encoding windows-1252 or latin-1", path)
+		}
+		return string(data), nil
+	}
+	return "", ValidateEncoding(encoding)
+}
+
+// decodeSingleByte converts Latin-1 text, in which every byte is the code
+// point of the same value, to UTF-8. high, if given, replaces the C1
+// control range 0x80-0x9F as Windows-1252 does.
+func decodeSingleByte(data []byte, high *[32]rune) string {
+	var text strings.Builder
+	text.Grow(len(data))
+	for _, b := range data {
+		if high != nil && b >= 0x80 && b <= 0x9F {
+			text.WriteRune(high[b-0x80])
+		} else {
+			text.WriteRune(rune(b))
+		}
+	}
+	return text.String()
+}
+
+// windows1252 is the Windows-1252 mapping for bytes 0x80-0x9F. The five
+// bytes it leaves undefined keep their Latin-1 value.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
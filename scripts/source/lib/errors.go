@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors wrapped by the readers, so callers can tell the failures
+// apart with errors.Is whatever the message says.
+var (
+	// ErrNoSheets is a workbook without any sheets
+	ErrNoSheets = errors.New("no sheets found")
+	// ErrMissingColumns is a sheet without its required column headers; the
+	// error is a *ParseError with the header row that was found
+	ErrMissingColumns = errors.New("required columns not found")
+	// ErrNoEvents is a calendar with no event that could be used
+	ErrNoEvents = errors.New("no events")
+	// ErrUnparseableDate is a date in none of the formats ParseFlexibleDate
+	// accepts
+	ErrUnparseableDate = errors.New("unable to parse date")
+)
+
+// ParseError reports a spreadsheet that has the wrong shape, with enough
+// context for whoever maintains the sheet to find the problem.
+type ParseError struct {
+	File string
+	// Row is the 1-based spreadsheet row the problem was found on, or 0
+	Row    int
+	Detail string
+	// Header is the row that was checked for column headers, if any
+	Header []string
+	// Err is the sentinel this error wraps, such as ErrMissingColumns
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Row > 0 {
+		return fmt.Sprintf("%s row %d: %s", e.File, e.Row, e.Detail)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Detail)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// HeaderSummary lists the header cells that were found, quoted so empty or
+// oddly spaced cells stand out, e.g. `"Nombre", "Correo", ""`.
+func (e *ParseError) HeaderSummary() string {
+	if len(e.Header) == 0 {
+		return "(no header row found)"
+	}
+	quoted := make([]string, len(e.Header))
+	for i, cell := range e.Header {
+		quoted[i] = fmt.Sprintf("%q", cell)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// missingColumns builds the ParseError for a sheet without its required
+// headers, pointing at the first non-empty row among the first scanRows.
+func missingColumns(file string, rows [][]string, scanRows int, detail string) *ParseError {
+	err := &ParseError{File: file, Detail: detail, Err: ErrMissingColumns}
+	for i := 0; i < scanRows && i < len(rows); i++ {
+		if strings.Join(rows[i], "") != "" {
+			err.Row = i + 1
+			err.Header = rows[i]
+			break
+		}
+	}
+	return err
+}
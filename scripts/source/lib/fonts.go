@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// embeddedFont is the family name a CertificateConfig.FontFamily TTF file is
+// registered under.
+const embeddedFont = "embedded"
+
+// coreFonts maps the -font-family names for the built-in PDF fonts to their
+// gofpdf family names. They need no font file.
+var coreFonts = map[string]string{
+	"times":     "Times",
+	"helvetica": "Helvetica",
+	"courier":   "Courier",
+}
+
+// FontFamilies returns the core font names accepted as FontFamily, sorted.
+func FontFamilies() []string {
+	var names []string
+	for name := range coreFonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isTTF(family string) bool {
+	return strings.EqualFold(filepath.Ext(family), ".ttf")
+}
+
+// ValidateFontFamily checks a CertificateConfig.FontFamily value: empty, a
+// core font name, or a readable .ttf file.
+func ValidateFontFamily(family string) error {
+	if family == "" {
+		return nil
+	}
+	if isTTF(family) {
+		_, err := os.Stat(family)
+		return err
+	}
+	if _, ok := coreFonts[strings.ToLower(family)]; !ok {
+		return fmt.Errorf("font %q must be one of %s or a .ttf file", family, strings.Join(FontFamilies(), ", "))
+	}
+	return nil
+}
+
+// registerFont embeds a TTF FontFamily. A TTF file holds a single style, so
+// it is registered for bold and italic too; those render in the regular face.
+// The file is read here rather than by AddUTF8Font, which resolves every
+// path (even an absolute one) under gofpdf's font directory.
+func registerFont(pdf *gofpdf.Fpdf, config CertificateConfig) {
+	if !isTTF(config.FontFamily) {
+		return
+	}
+	data, err := os.ReadFile(config.FontFamily)
+	if err != nil {
+		pdf.SetError(err)
+		return
+	}
+	for _, style := range []string{"", "B", "I", "BI"} {
+		pdf.AddUTF8FontFromBytes(embeddedFont, style, data)
+	}
+}
+
+// setFont selects the certificate's font family, Times unless FontFamily
+// says otherwise. Small print (the certificate ID and accreditation block)
+// stays in Helvetica.
+func setFont(pdf *gofpdf.Fpdf, config CertificateConfig, style string, size float64) {
+	family := "Times"
+	if isTTF(config.FontFamily) {
+		family = embeddedFont
+	} else if core, ok := coreFonts[strings.ToLower(config.FontFamily)]; ok {
+		family = core
+	}
+	pdf.SetFont(family, style, size)
+}
+
+// translator converts text for the font setFont selects. Core fonts use the
+// cp1252 encoding; an embedded TTF takes UTF-8 as is.
+func translator(pdf *gofpdf.Fpdf, config CertificateConfig) func(string) string {
+	if isTTF(config.FontFamily) {
+		return func(s string) string { return s }
+	}
+	return pdf.UnicodeTranslatorFromDescriptor("")
+}
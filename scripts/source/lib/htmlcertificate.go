@@ -0,0 +1,412 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// letterWidth and letterHeight are the US Letter page in portrait, in mm.
+const (
+	letterWidth  = 215.9
+	letterHeight = 279.4
+)
+
+// ptToMM converts a font size in points to the mm units of the page.
+const ptToMM = 25.4 / 72
+
+// svgText is one line of certificate text. Top and Height describe the
+// gofpdf cell the PDF prints it in; the text is centered vertically in it.
+type svgText struct {
+	X, Top, Height float64
+	// Size is in points, as passed to setFont
+	Size   float64
+	Bold   bool
+	Italic bool
+	// Anchor is "middle" for centered lines or "start"
+	Anchor    string
+	Fill      string
+	Family    template.CSS
+	Underline bool
+	Text      string
+}
+
+// Y is the text's baseline position, as dominant-baseline: central.
+func (t svgText) Y() float64 { return round2(t.Top + t.Height/2) }
+
+// FontSize is Size in page units.
+func (t svgText) FontSize() float64 { return round2(t.Size * ptToMM) }
+
+// round2 keeps coordinates in the page readable, e.g. 239.4 rather than
+// 239.39999999999998.
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+
+// svgImage is an embedded image in page units.
+type svgImage struct {
+	X, Y, Width, Height float64
+	URI                 template.URL
+}
+
+type svgRect struct {
+	X, Y, Width, Height, Stroke float64
+	Color                       string
+}
+
+// htmlCertificate is the data for htmlCertificateTemplate.
+type htmlCertificate struct {
+	Lang          string
+	Title         string
+	Width, Height float64
+	Orientation   string
+	Font          template.CSS
+	FontFace      template.CSS
+	Gradient      []string
+	Background    *svgImage
+	Rects         []svgRect
+	Images        []svgImage
+	Texts         []svgText
+	Rule          string
+	Watermark     *svgText
+	// Accreditation is the continuing education block, wrapped by the
+	// browser inside the box at AccreditationX/Width, ending at its Bottom
+	Accreditation  []string
+	AccreditationX float64
+	AccreditationW float64
+	AccreditationB float64
+	Board          string
+	BoardTop       float64
+	Abstract       *htmlAbstract
+}
+
+type htmlAbstract struct {
+	Heading, Topic, By, Date, Text string
+}
+
+// GenerateHTMLCertificate renders the same certificate as GenerateCertificate
+// as a single printable HTML page, for members who can't open a PDF. The
+// page is an SVG laid out at the PDF's positions, so it matches the PDF
+// when printed and scales down on a phone. Images and a .ttf FontFamily are
+// embedded, so the file stands alone. It is saved in outputDir as
+// COA_<Name>_<Date>.html and the path is returned. Only designs drawn from
+// a designLayout can be rendered this way; others are an error.
+func GenerateHTMLCertificate(config CertificateConfig, attendee Attendee, event EventInfo, outputDir string) (string, error) {
+	if config.Issued.IsZero() {
+		now := time.Now()
+		config.Issued = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	newDesign, ok := Designs[config.Design]
+	if !ok {
+		return "", fmt.Errorf("unknown design %q", config.Design)
+	}
+	design, ok := newDesign(config).(layoutDesign)
+	if !ok {
+		return "", fmt.Errorf("design %q has no HTML layout", config.Design)
+	}
+	layout := design.layout
+	text := textFor(config.Lang)
+	page := htmlCertificate{
+		Lang:        config.Lang,
+		Title:       text.Title,
+		Width:       letterHeight,
+		Height:      letterWidth,
+		Orientation: "landscape",
+		Font:        cssFontFamily(config.FontFamily),
+		Rule:        textFill(config, 0, 0, 0),
+	}
+	if page.Lang == "" {
+		page.Lang = "en"
+	}
+	if config.Orientation == "P" {
+		page.Width, page.Height, page.Orientation = letterWidth, letterHeight, "portrait"
+	}
+	if isTTF(config.FontFamily) {
+		data, err := os.ReadFile(config.FontFamily)
+		if err != nil {
+			return "", err
+		}
+		page.FontFace = template.CSS(fmt.Sprintf("@font-face { font-family: CertificateFont; src: url(data:font/ttf;base64,%s); }", base64.StdEncoding.EncodeToString(data)))
+	}
+
+	if config.Background != "" {
+		if strings.HasPrefix(config.Background, gradientPrefix) {
+			top, bottom, err := parseGradient(config.Background)
+			if err != nil {
+				return "", err
+			}
+			page.Gradient = []string{cssColor(top.R, top.G, top.B), cssColor(bottom.R, bottom.G, bottom.B)}
+		} else {
+			uri, _, _, err := imageDataURI(config.Background)
+			if err != nil {
+				return "", err
+			}
+			page.Background = &svgImage{Width: page.Width, Height: page.Height, URI: uri}
+		}
+	}
+	if config.Watermark != "" {
+		// Without font metrics, estimate the width at 0.6em per character
+		diagonal := math.Hypot(page.Width, page.Height)
+		size := math.Min(diagonal*0.8/(0.6*float64(len([]rune(config.Watermark))))/ptToMM, page.Height/3/ptToMM)
+		page.Watermark = &svgText{X: page.Width / 2, Top: page.Height / 2, Size: size, Bold: true, Anchor: "middle", Fill: "rgb(120,120,120)", Text: config.Watermark}
+	}
+
+	for _, frame := range layout.Frames {
+		page.Rects = append(page.Rects, svgRect{
+			X: frame.Inset, Y: frame.Inset, Width: round2(page.Width - 2*frame.Inset), Height: round2(page.Height - 2*frame.Inset),
+			Stroke: frame.Width, Color: cssColor(frame.Color.R, frame.Color.G, frame.Color.B),
+		})
+	}
+	if layout.Header {
+		headerX := 25.0
+		logo := config.LogoPath
+		if logo == "" {
+			logo = DefaultLogoPath
+		}
+		logoWidth := config.LogoWidth
+		if logoWidth == 0 {
+			logoWidth = 50
+		}
+		// The skyline is optional, as in the PDF
+		if uri, w, h, err := imageDataURI(logo); err == nil && w > 0 {
+			page.Images = append(page.Images, svgImage{X: 25, Y: 15, Width: logoWidth, Height: round2(logoWidth * float64(h) / float64(w)), URI: uri})
+			headerX += logoWidth + 5
+		}
+		if !config.NoLogoText {
+			// The header sits at a fixed height, above the centered body
+			page.Texts = append(page.Texts, svgText{
+				X: headerX, Top: 25, Height: 10, Size: 24, Bold: true,
+				Anchor: "start", Fill: textFill(config, 0, 0, 0), Text: "LITTLE ROCK ENGINEERS CLUB",
+			})
+		}
+	}
+	offsetY := (page.Height - landscapeHeight) / 2
+	for _, line := range layout.Lines {
+		s := line.Text(text, config, attendee, event)
+		if s == "" {
+			continue
+		}
+		page.Texts = append(page.Texts, svgText{
+			X: page.Width / 2, Top: line.Top + offsetY, Height: line.Height, Size: line.Size,
+			Bold: strings.Contains(line.Style, "B"), Italic: strings.Contains(line.Style, "I"),
+			Anchor: "middle", Fill: textFill(config, line.Color.R, line.Color.G, line.Color.B),
+			Underline: line.Underline != 0, Text: s,
+		})
+	}
+	if config.BoardName != "" {
+		page.Board = fmt.Sprintf(text.Board, strings.TrimSpace(config.BoardName))
+		page.BoardTop = layout.BoardTop + offsetY
+	}
+
+	// Small print, placed as drawAccreditation, drawSignatureBlock, and
+	// drawCertificateID place it
+	if config.ProviderNumber != "" {
+		x, width, bottom := accreditationBox(config, page.Width, page.Height, design.FrameInset())
+		page.AccreditationX, page.AccreditationW, page.AccreditationB = x, round2(width), bottom
+		provider := text.ProviderNo + config.ProviderNumber
+		if config.ProviderName != "" {
+			provider = text.Provider + config.ProviderName + " - " + provider
+		}
+		page.Accreditation = []string{text.Accreditation, provider}
+		if config.AccreditationText != "" {
+			page.Accreditation = append(page.Accreditation, strings.TrimSpace(config.AccreditationText))
+		}
+	}
+	if config.SignaturePath != "" {
+		blockX, lineY := round2(page.Width-74.4), round2(page.Height-27.9)
+		uri, w, h, err := imageDataURI(config.SignaturePath)
+		if err != nil {
+			return "", err
+		}
+		if h > 0 {
+			imgWidth, imgHeight := 15*float64(w)/float64(h), 15.0
+			if imgWidth > 55 {
+				imgWidth, imgHeight = 55, float64(h)*55/float64(w)
+			}
+			page.Images = append(page.Images, svgImage{X: round2(blockX + (55-imgWidth)/2), Y: round2(lineY - 1 - imgHeight), Width: round2(imgWidth), Height: round2(imgHeight), URI: uri})
+		}
+		page.Rects = append(page.Rects, svgRect{X: blockX, Y: lineY, Width: 55, Stroke: 0.2, Color: page.Rule})
+		signature := func(top float64, s string) svgText {
+			return svgText{X: blockX + 27.5, Top: top, Height: 6, Size: 12, Anchor: "middle", Fill: textFill(config, 0, 0, 0), Text: s}
+		}
+		if config.Signatory != "" {
+			page.Texts = append(page.Texts, signature(lineY+1, config.Signatory))
+		}
+		page.Texts = append(page.Texts, signature(lineY+7, text.DateIssued+text.localizeDate(config.Issued.Format(text.DateLayout))))
+	}
+	label := text.CertificateID + CertificateID(attendee, event)
+	if config.Number != "" {
+		label = text.CertificateNo + config.Number
+	}
+	page.Texts = append(page.Texts, svgText{X: 20, Top: page.Height - 24, Height: 4, Size: 8, Anchor: "start", Fill: textFill(config, 120, 120, 120), Family: smallPrintFont, Text: label})
+
+	if config.AbstractPage && event.Abstract != "" {
+		page.Abstract = &htmlAbstract{Heading: text.AbstractTitle, Topic: event.Topic, By: text.By + event.Speaker, Date: event.DisplayDate, Text: event.Abstract}
+	}
+
+	path := filepath.Join(outputDir, HTMLCertificateFilename(attendee, event))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := htmlCertificateTemplate.Execute(file, page); err != nil {
+		file.Close()
+		return "", err
+	}
+	return path, file.Close()
+}
+
+// HasHTMLLayout reports whether GenerateHTMLCertificate can render the
+// named design.
+func HasHTMLLayout(design string) bool {
+	newDesign, ok := Designs[design]
+	if !ok {
+		return false
+	}
+	_, ok = newDesign(CertificateConfig{Design: design}).(layoutDesign)
+	return ok
+}
+
+// HTMLCertificateFilename is CertificateFilename for GenerateHTMLCertificate:
+// COA_<Name>_<Date>.html.
+func HTMLCertificateFilename(attendee Attendee, event EventInfo) string {
+	return strings.TrimSuffix(CertificateFilename(attendee, event), ".pdf") + ".html"
+}
+
+// smallPrintFont is the HTML stand-in for the Helvetica the PDF uses for
+// the certificate ID and accreditation block.
+const smallPrintFont template.CSS = "Helvetica, Arial, sans-serif"
+
+// cssFontFamily is the CSS font stack for a CertificateConfig.FontFamily.
+func cssFontFamily(family string) template.CSS {
+	if isTTF(family) {
+		return "CertificateFont, serif"
+	}
+	switch coreFonts[strings.ToLower(family)] {
+	case "Helvetica":
+		return smallPrintFont
+	case "Courier":
+		return `"Courier New", Courier, monospace`
+	}
+	return `"Times New Roman", Times, serif`
+}
+
+func cssColor(r, g, b int) string {
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, b)
+}
+
+// textFill is setTextColor for HTML: the design's color unless
+// config.TextColor overrides it.
+func textFill(config CertificateConfig, r, g, b int) string {
+	if config.TextColor != nil {
+		return cssColor(config.TextColor.R, config.TextColor.G, config.TextColor.B)
+	}
+	return cssColor(r, g, b)
+}
+
+// imageMIMETypes maps ImageType's results to MIME types for data URIs.
+var imageMIMETypes = map[string]string{"PNG": "image/png", "JPG": "image/jpeg", "GIF": "image/gif"}
+
+// imageDataURI reads an image for embedding, returning it as a data URI
+// with its size in pixels.
+func imageDataURI(path string) (template.URL, int, int, error) {
+	imageType, err := ImageType(path)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	size, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("%s: %v", path, err)
+	}
+	uri := "data:" + imageMIMETypes[imageType] + ";base64," + base64.StdEncoding.EncodeToString(data)
+	return template.URL(uri), size.Width, size.Height, nil
+}
+
+var htmlCertificateTemplate = template.Must(template.New("certificate").Funcs(template.FuncMap{
+	"add":            func(a, b float64) float64 { return round2(a + b) },
+	"watermarkAngle": func(w, h float64) float64 { return -math.Atan2(h, w) * 180 / math.Pi },
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+{{.FontFace}}
+@page { size: letter {{.Orientation}}; margin: 0; }
+body { margin: 0; background: #e0e0e0; }
+.page { display: block; width: 100%; max-width: {{.Width}}mm; margin: 1em auto; background: #fff; box-shadow: 0 1px 4px rgba(0,0,0,0.3); }
+svg.page { height: auto; }
+.abstract { box-sizing: border-box; padding: 25mm; font-family: {{.Font}}; text-align: center; }
+.abstract h1 { font-size: 24pt; }
+.abstract .topic { font-size: 18pt; font-style: italic; margin: 0; }
+.abstract .details { font-size: 14pt; margin: 0.3em 0; }
+.abstract .text { font-size: 12pt; text-align: justify; margin-top: 2em; white-space: pre-line; }
+/* Inside the SVG one CSS pixel is one mm of the page */
+.small { font-family: Helvetica, Arial, sans-serif; font-size: 2.47px; color: rgb(60,60,60); text-align: center; line-height: 3px; }
+.board { font-family: {{.Font}}; font-size: 3.88px; font-style: italic; text-align: center; line-height: 5px; }
+@media print {
+  body { background: none; }
+  .page { width: {{.Width}}mm; max-width: none; margin: 0; box-shadow: none; break-after: page; }
+  svg.page { height: {{.Height}}mm; }
+}
+</style>
+</head>
+<body>
+<svg class="page" viewBox="0 0 {{.Width}} {{.Height}}" xmlns="http://www.w3.org/2000/svg" role="img" aria-label="{{.Title}}">
+{{- if .Gradient}}
+<defs><linearGradient id="background" x1="0" y1="0" x2="0" y2="1"><stop offset="0" stop-color="{{index .Gradient 0}}"/><stop offset="1" stop-color="{{index .Gradient 1}}"/></linearGradient></defs>
+<rect width="{{.Width}}" height="{{.Height}}" fill="url(#background)"/>
+{{- end}}
+{{- with .Background}}
+<image x="0" y="0" width="{{.Width}}" height="{{.Height}}" preserveAspectRatio="none" href="{{.URI}}"/>
+{{- end}}
+{{- with .Watermark}}
+<text x="{{.X}}" y="{{.Y}}" font-size="{{.FontSize}}" font-weight="bold" text-anchor="middle" dominant-baseline="central" fill="{{.Fill}}" fill-opacity="0.15" font-family="{{$.Font}}" transform="rotate({{printf "%.2f" (watermarkAngle $.Width $.Height)}} {{.X}} {{.Y}})">{{.Text}}</text>
+{{- end}}
+{{- range .Rects}}
+{{- if .Height}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="none" stroke="{{.Color}}" stroke-width="{{.Stroke}}"/>
+{{- else}}
+<line x1="{{.X}}" y1="{{.Y}}" x2="{{add .X .Width}}" y2="{{.Y}}" stroke="{{.Color}}" stroke-width="{{.Stroke}}"/>
+{{- end}}
+{{- end}}
+{{- range .Images}}
+<image x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" href="{{.URI}}"/>
+{{- end}}
+{{- range .Texts}}
+<text x="{{.X}}" y="{{.Y}}" font-size="{{.FontSize}}"{{if .Bold}} font-weight="bold"{{end}}{{if .Italic}} font-style="italic"{{end}}{{if .Underline}} text-decoration="underline"{{end}} text-anchor="{{.Anchor}}" dominant-baseline="central" fill="{{.Fill}}" font-family="{{if .Family}}{{.Family}}{{else}}{{$.Font}}{{end}}">{{.Text}}</text>
+{{- end}}
+{{- if .Board}}
+<foreignObject x="20" y="{{.BoardTop}}" width="{{add .Width -40}}" height="6"><div xmlns="http://www.w3.org/1999/xhtml" class="board">{{.Board}}</div></foreignObject>
+{{- end}}
+{{- if .Accreditation}}
+<foreignObject x="{{.AccreditationX}}" y="{{add .AccreditationB -30}}" width="{{.AccreditationW}}" height="27"><div xmlns="http://www.w3.org/1999/xhtml" class="small" style="display: flex; flex-direction: column; justify-content: flex-end; height: 100%;">
+{{- range $i, $line := .Accreditation}}<div{{if eq $i 0}} style="font-weight: bold;"{{end}}>{{$line}}</div>{{end -}}
+</div></foreignObject>
+{{- end}}
+</svg>
+{{- with .Abstract}}
+<section class="page abstract">
+<h1>{{.Heading}}</h1>
+<p class="topic">{{.Topic}}</p>
+<p class="details">{{.By}}</p>
+<p class="details">{{.Date}}</p>
+<p class="text">{{.Text}}</p>
+</section>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// imageSignatures are the leading bytes of each image format gofpdf reads.
+var imageSignatures = []struct {
+	magic     []byte
+	imageType string
+}{
+	{[]byte("\x89PNG\r\n\x1a\n"), "PNG"},
+	{[]byte{0xFF, 0xD8, 0xFF}, "JPG"},
+	{[]byte("GIF87a"), "GIF"},
+	{[]byte("GIF89a"), "GIF"},
+}
+
+// ImageType returns the gofpdf image type ("PNG", "JPG", or "GIF") of the
+// file at path, judged by its contents so a misnamed file still works.
+// Anything else is an error, which callers can report before generating.
+func ImageType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, 8)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	for _, sig := range imageSignatures {
+		if bytes.HasPrefix(header[:n], sig.magic) {
+			return sig.imageType, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a PNG, JPEG, or GIF image", path)
+}
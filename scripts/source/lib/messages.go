@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"sort"
+	"strings"
+)
+
+// certificateText holds the fixed wording printed on certificates in one
+// language. Earned, BanquetEarned, and Conducted take the PDH phrase or date
+// as their %s.
+type certificateText struct {
+	Title         string
+	Certify       string
+	Earned        string
+	Presentation  string
+	Conducted     string
+	Banquet       string
+	PresentedTo   string
+	BanquetEarned string
+	By            string
+	BanquetPlace  string
+	DateIssued    string
+	CertificateID string
+	CertificateNo string
+	AbstractTitle string
+	Accreditation string
+	Provider      string
+	ProviderNo    string
+	// Board takes the -board-name as its %s
+	Board string
+
+	// DateLayout is the default layout for dates in this language; month and
+	// weekday names are translated from English after formatting
+	DateLayout string
+	Months     []string
+	Weekdays   []string
+
+	HourSingular string
+	HourPlural   string
+	// Numbers spells out whole hours from zero
+	Numbers []string
+}
+
+var certificateTexts = map[string]certificateText{
+	"en": {
+		Title:         "CERTIFICATE OF ATTENDANCE",
+		Certify:       "This is to certify that",
+		Earned:        "Earned %s by attending",
+		Presentation:  "the presentation by:",
+		Conducted:     "Conducted in Little Rock, Arkansas on %s",
+		Banquet:       "Annual Awards Banquet",
+		PresentedTo:   "Presented to",
+		BanquetEarned: "for earning %s at the keynote presentation",
+		By:            "by ",
+		BanquetPlace:  "Little Rock, Arkansas - ",
+		DateIssued:    "Date Issued: ",
+		CertificateID: "Certificate ID: ",
+		CertificateNo: "Certificate No. ",
+		AbstractTitle: "Session Abstract",
+		Accreditation: "CONTINUING EDUCATION",
+		Provider:      "Provider: ",
+		ProviderNo:    "Provider No. ",
+		Board:         "This certificate may be used to document continuing education for the %s.",
+		DateLayout:    "January 2, 2006",
+		HourSingular:  "Professional Development Hour (PDH)",
+		HourPlural:    "Professional Development Hours (PDH)",
+		Numbers:       []string{"", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"},
+	},
+	"es": {
+		Title:         "CERTIFICADO DE ASISTENCIA",
+		Certify:       "Por medio de la presente se certifica que",
+		Earned:        "Obtuvo %s por asistir a",
+		Presentation:  "la presentación de:",
+		Conducted:     "Realizada en Little Rock, Arkansas el %s",
+		Banquet:       "Banquete Anual de Premios",
+		PresentedTo:   "Otorgado a",
+		BanquetEarned: "por obtener %s en la conferencia principal",
+		By:            "por ",
+		BanquetPlace:  "Little Rock, Arkansas - ",
+		DateIssued:    "Fecha de emisión: ",
+		CertificateID: "ID del certificado: ",
+		CertificateNo: "Certificado n.º ",
+		AbstractTitle: "Resumen de la sesión",
+		Accreditation: "EDUCACIÓN CONTINUA",
+		Provider:      "Proveedor: ",
+		ProviderNo:    "Proveedor n.º ",
+		Board:         "Este certificado puede usarse para acreditar educación continua ante %s.",
+		DateLayout:    "2 de January de 2006",
+		Months:        []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		Weekdays:      []string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		HourSingular:  "Hora de Desarrollo Profesional (PDH)",
+		HourPlural:    "Horas de Desarrollo Profesional (PDH)",
+		Numbers:       []string{"", "una", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve", "diez"},
+	},
+}
+
+// Languages returns the certificate languages, sorted.
+func Languages() []string {
+	var langs []string
+	for lang := range certificateTexts {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// DateLayout returns the default date layout for a language.
+func DateLayout(lang string) string {
+	return textFor(lang).DateLayout
+}
+
+// textFor returns the wording for lang, defaulting to English.
+func textFor(lang string) certificateText {
+	if text, ok := certificateTexts[lang]; ok {
+		return text
+	}
+	return certificateTexts["en"]
+}
+
+// localizeDate translates English month and weekday names in a formatted
+// date. Full names are replaced before abbreviations.
+func (t certificateText) localizeDate(date string) string {
+	if t.Months == nil {
+		return date
+	}
+	var pairs []string
+	for i, month := range t.Months {
+		pairs = append(pairs, englishMonths[i], month)
+	}
+	for i, day := range t.Weekdays {
+		pairs = append(pairs, englishWeekdays[i], day)
+	}
+	for i, month := range t.Months {
+		pairs = append(pairs, englishMonths[i][:3], month[:3])
+	}
+	for i, day := range t.Weekdays {
+		pairs = append(pairs, englishWeekdays[i][:3], string([]rune(day)[:3]))
+	}
+	return strings.NewReplacer(pairs...).Replace(date)
+}
+
+var englishMonths = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var englishWeekdays = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
@@ -0,0 +1,75 @@
+package lib
+
+import "strings"
+
+// nameColumns is where a sheet keeps attendee names: a single full-name
+// column, or separate first and last name columns when there is none.
+type nameColumns struct {
+	full, first, last int
+}
+
+// findNameColumns picks the name columns from a header row. An exact
+// "Name" or "Full Name" header wins, so a "First Name" column to its left
+// isn't taken for the whole name. Without one, "First Name" and "Last Name"
+// columns are combined, and failing that the first header containing
+// "name" is used.
+func findNameColumns(header []string) nameColumns {
+	cols := nameColumns{full: -1, first: -1, last: -1}
+	partial := -1
+	for i, cell := range header {
+		if partial == -1 && HeaderHas(cell, "name") {
+			partial = i
+		}
+		switch FieldKey(cell) {
+		case "name", "full_name":
+			if cols.full == -1 {
+				cols.full = i
+			}
+		case "first_name", "first", "given_name":
+			if cols.first == -1 {
+				cols.first = i
+			}
+		case "last_name", "last", "surname", "family_name":
+			if cols.last == -1 {
+				cols.last = i
+			}
+		}
+	}
+	if cols.full == -1 && (cols.first == -1 || cols.last == -1) {
+		cols.full = partial
+	}
+	if cols.full != -1 {
+		cols.first, cols.last = -1, -1
+	}
+	return cols
+}
+
+// found reports whether the header had any name column.
+func (c nameColumns) found() bool {
+	return c.full != -1 || c.first != -1
+}
+
+// has reports whether column i holds the name or part of it.
+func (c nameColumns) has(i int) bool {
+	return i == c.full || i == c.first || i == c.last
+}
+
+// name returns the row's name and whether it was joined from first and last
+// name columns, in which case it is already "First Last".
+func (c nameColumns) name(row []string) (string, bool) {
+	if c.full != -1 {
+		if c.full < len(row) {
+			return strings.TrimSpace(row[c.full]), false
+		}
+		return "", false
+	}
+	var parts []string
+	for _, col := range []int{c.first, c.last} {
+		if col < len(row) {
+			if part := strings.TrimSpace(row[col]); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, " "), true
+}
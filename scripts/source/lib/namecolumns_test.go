@@ -0,0 +1,36 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadAttendanceNameColumns(t *testing.T) {
+	tests := []struct {
+		header []string
+		row    []string
+		want   string
+	}{
+		{[]string{"Name", "Name Tag"}, []string{"Smith, John", "Johnny"}, "John Smith"},
+		{[]string{"Name Tag", "Name"}, []string{"Johnny", "Smith, John"}, "John Smith"},
+		{[]string{"First Name", "Last Name"}, []string{"John", "Smith"}, "John Smith"},
+		{[]string{"Last Name", "First Name"}, []string{"Smith", "John"}, "John Smith"},
+		{[]string{"First Name", "Name"}, []string{"John", "Smith, John"}, "John Smith"},
+		{[]string{"Full Name", "Nickname"}, []string{"John Smith", "Johnny"}, "John Smith"},
+		{[]string{"Nickname", "Full Name"}, []string{"Johnny", "John Smith"}, "John Smith"},
+		{[]string{"Attendee Name", "Email"}, []string{"John Smith", "j@x.com"}, "John Smith"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.header, ","), func(t *testing.T) {
+			data := strings.Join(tt.header, ",") + "\n\"" + strings.Join(tt.row, "\",\"") + "\"\n"
+			path := writeFile(t, "attendance.csv", []byte(data))
+			attendees, err := ReadAttendance(path, ReadOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(attendees) != 1 || attendees[0].Name != tt.want {
+				t.Errorf("ReadAttendance = %+v, want one attendee named %q", attendees, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package lib
+
+import "strings"
+
+// readNameList reads a plain text attendance list, one name per line, as
+// collected at the door for small meetings. Blank lines are skipped, and
+// each name may be "Last, First" or "First Last" as in the spreadsheets.
+// The file is decoded like a CSV, so the encoding applies.
+func readNameList(path string, opts ReadOptions) ([]Attendee, error) {
+	text, err := ReadCSVText(path, opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	var attendees []Attendee
+	for _, line := range strings.Split(text, "\n") {
+		if line = cleanCell(line); line != "" {
+			attendees = append(attendees, Attendee{Name: ConvertNameFormat(line), PDH: DefaultPDH})
+		}
+	}
+	return attendees, nil
+}
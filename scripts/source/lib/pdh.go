@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPDH is the flat credit for a regular meeting, used when the
+// attendance sheet has no sign-in/sign-out times for an attendee.
+const DefaultPDH = 1.0
+
+// isSignInHeader and isSignOutHeader recognise the optional attendance-time
+// columns ("sign_in", "Sign In", "Sign-in Time").
+func isSignInHeader(header string) bool {
+	return strings.Contains(normalizeHeader(header), "signin")
+}
+
+func isSignOutHeader(header string) bool {
+	return strings.Contains(normalizeHeader(header), "signout")
+}
+
+// isPDHHeader recognises the optional per-attendee credit column ("PDH",
+// "PDH Earned").
+func isPDHHeader(header string) bool {
+	return strings.Contains(normalizeHeader(header), "pdh")
+}
+
+// parsePDH reads a per-attendee credit such as "1.5" or "2 PDH".
+func parsePDH(value string) (float64, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty PDH value")
+	}
+	hours, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || hours <= 0 || math.IsInf(hours, 0) || math.IsNaN(hours) {
+		return 0, fmt.Errorf("PDH %q is not a positive number", value)
+	}
+	return hours, nil
+}
+
+func normalizeHeader(header string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(header))
+}
+
+// parseClockTime reads a time of day as typed ("9:00 AM", "13:30") or as an
+// Excel time serial, where the fraction is the portion of the day.
+func parseClockTime(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"3:04 PM", "3:04PM", "3:04:05 PM", "15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, strings.ToUpper(value)); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		_, frac := math.Modf(serial)
+		return time.Duration(frac * float64(24*time.Hour)).Round(time.Minute), nil
+	}
+	return 0, fmt.Errorf("unrecognized time %q", value)
+}
+
+// attendedHours returns the time between sign-in and sign-out, rounded to the
+// nearest quarter hour. A stay under 7.5 minutes is 0.
+func attendedHours(signIn, signOut string) (float64, error) {
+	in, err := parseClockTime(signIn)
+	if err != nil {
+		return 0, err
+	}
+	out, err := parseClockTime(signOut)
+	if err != nil {
+		return 0, err
+	}
+	if out <= in {
+		return 0, fmt.Errorf("sign-out %s is not after sign-in %s", signOut, signIn)
+	}
+	return math.Round(out.Hours()*4-in.Hours()*4) / 4, nil
+}
+
+// PDHPhrase is the credit wording used on certificates, e.g. "one (1)
+// Professional Development Hour (PDH)" or "2.75 Professional Development
+// Hours (PDH)", in the given language.
+func PDHPhrase(hours float64, lang string) string {
+	text := textFor(lang)
+	unit := text.HourPlural
+	if hours == 1 {
+		unit = text.HourSingular
+	}
+	if hours > 0 && hours == math.Trunc(hours) && int(hours) < len(text.Numbers) {
+		return fmt.Sprintf("%s (%d) %s", text.Numbers[int(hours)], int(hours), unit)
+	}
+	return fmt.Sprintf("%g %s", hours, unit)
+}
@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"strings"
+)
+
+// Redactor masks attendee details in console and log output. Masking is off
+// unless Emails or Names is set.
+type Redactor struct {
+	Emails bool
+	Names  bool
+}
+
+// Email masks the local part of an address, e.g. john@x.com -> j***@x.com.
+func (r Redactor) Email(email string) string {
+	if !r.Emails || email == "" {
+		return email
+	}
+	local, domain, found := strings.Cut(email, "@")
+	if !found || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
+
+// Name keeps the first letter of each word, e.g. John Smith -> J*** S***.
+func (r Redactor) Name(name string) string {
+	if !r.Names || name == "" {
+		return name
+	}
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(runes[0]) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// Scrub masks an attendee's name and email wherever they appear in free
+// text, such as SMTP error messages.
+func (r Redactor) Scrub(text string, attendee Attendee) string {
+	if attendee.Email != "" {
+		text = strings.ReplaceAll(text, attendee.Email, r.Email(attendee.Email))
+	}
+	if attendee.Name != "" {
+		text = strings.ReplaceAll(text, attendee.Name, r.Name(attendee.Name))
+	}
+	return text
+}
@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ReadOptions are the settings the spreadsheet readers share. Tools fill
+// them in from their flags; the zero value reads UTF-8.
+type ReadOptions struct {
+	// Encoding is the character encoding of CSV and .txt input, one of
+	// CSVEncodings
+	Encoding string
+	// Strict makes the calendar readers fail on data they would otherwise
+	// step around: rows with a date but no topic or speaker, and dates that
+	// don't parse. Tools set it from their -strict flag so a malformed
+	// calendar stops the run instead of picking the wrong event.
+	Strict bool
+	// PII masks attendee names and emails in the readers' warnings
+	PII Redactor
+}
+
+// ReadRows returns the cells of an input file as rows of strings, so the
+// roster, attendance, and calendar readers share one column-detection path
+// regardless of format. CSV files are read directly; anything else is opened
+// as an Excel workbook. A "#SheetName" suffix (as in "club.xlsx#Roster")
+// selects a sheet, otherwise the first sheet is used. kind names the file in
+// error messages. Every cell is passed through cleanCell.
+func ReadRows(path string, kind string, opts ReadOptions) ([][]string, error) {
+	path, sheet := splitSheet(path)
+	requested := sheet
+
+	var rows [][]string
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		if sheet != "" {
+			return nil, fmt.Errorf("%s file %s is a CSV and has no sheet %q", kind, path, sheet)
+		}
+		var err error
+		rows, err = readCSVRows(path, opts.Encoding)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if isLegacyExcel(path) {
+			return nil, fmt.Errorf("%s file %s is a legacy Excel 97-2003 workbook (or a password-protected one), which can't be read; open it in Excel or LibreOffice and save it as .xlsx or .csv", kind, path)
+		}
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("%w in %s file", ErrNoSheets, kind)
+		}
+		if sheet == "" {
+			sheet = sheets[0]
+		} else if sheet = findSheet(sheets, sheet); sheet == "" {
+			return nil, fmt.Errorf("%s file %s has no sheet %q (sheets: %s)", kind, path, requested, strings.Join(sheets, ", "))
+		}
+
+		rows, err = f.GetRows(sheet)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = cleanCell(cell)
+		}
+	}
+	return rows, nil
+}
+
+// oleSignature starts every OLE2 compound file, the container used by legacy
+// .xls workbooks. .xlsx files are zip archives instead.
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// isLegacyExcel reports whether a file is an OLE2 workbook that excelize
+// can't open. Unreadable files return false so the real error surfaces later.
+func isLegacyExcel(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(oleSignature))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, oleSignature)
+}
+
+// splitSheet separates a "#SheetName" suffix from a workbook path. A path
+// that exists as given is never split, so a file whose name really contains
+// "#" still opens.
+func splitSheet(path string) (string, string) {
+	i := strings.LastIndex(path, "#")
+	if i < 0 {
+		return path, ""
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, ""
+	}
+	return path[:i], path[i+1:]
+}
+
+// findSheet returns the workbook's name for the requested sheet, matching
+// case-insensitively as Excel does, or "" if there is no such sheet.
+func findSheet(sheets []string, name string) string {
+	for _, sheet := range sheets {
+		if strings.EqualFold(sheet, name) {
+			return sheet
+		}
+	}
+	return ""
+}
+
+// cleanCell collapses embedded newlines, tabs, and repeated spaces left by
+// copy-paste into single spaces, and trims the ends.
+func cleanCell(cell string) string {
+	return strings.Join(strings.Fields(cell), " ")
+}
+
+// HeaderHas reports whether a column header mentions keyword, ignoring
+// case, so "Full Name" and "Email Address" from form exports count as name
+// and email columns. Readers take the first matching column.
+func HeaderHas(header string, keyword string) bool {
+	return strings.Contains(strings.ToLower(header), keyword)
+}
+
+func readCSVRows(path string, encoding string) ([][]string, error) {
+	text, err := ReadCSVText(path, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	// Spreadsheet exports often have ragged rows
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFile writes data to name in a fresh temporary directory and returns
+// its path.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadRowsEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+		want     [][]string
+	}{
+		{
+			name: "UTF-8 byte order mark is dropped from the first header",
+			data: []byte("\xef\xbb\xbfName,Email\nJosé Núñez,j@x.com\n"),
+			want: [][]string{{"Name", "Email"}, {"José Núñez", "j@x.com"}},
+		},
+		{
+			name:     "Windows-1252 accents and curly quotes",
+			data:     []byte("Name,Note\nJos\xe9 N\xfa\xf1ez,\x93PE\x94 \x96 retired\n"),
+			encoding: "windows-1252",
+			want:     [][]string{{"Name", "Note"}, {"José Núñez", "“PE” – retired"}},
+		},
+		{
+			name:     "Latin-1 leaves the C1 range as is",
+			data:     []byte("Name\nFran\xe7ois\n"),
+			encoding: "latin-1",
+			want:     [][]string{{"Name"}, {"François"}},
+		},
+		{
+			name:     "encoding names are matched loosely",
+			data:     []byte("Name\nJos\xe9\n"),
+			encoding: "CP1252",
+			want:     [][]string{{"Name"}, {"José"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "attendance.csv", tt.data)
+			got, err := ReadRows(path, "attendance", ReadOptions{Encoding: tt.encoding})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadRows = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRowsUnsupportedEncoding(t *testing.T) {
+	path := writeFile(t, "attendance.csv", []byte("Name\n"))
+	if _, err := ReadRows(path, "attendance", ReadOptions{Encoding: "utf-16"}); err == nil {
+		t.Error("ReadRows with -encoding utf-16 succeeded, want an error")
+	}
+}
+
+func TestCleanCell(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"Topic\n", "Topic"},
+		{"A\tB", "A B"},
+		{"A\r\nB", "A B"},
+		{"  Jane   Doe  ", "Jane Doe"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanCell(tt.cell); got != tt.want {
+			t.Errorf("cleanCell(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestReadRowsCleansCells(t *testing.T) {
+	path := writeFile(t, "calendar.csv", []byte("\"Topic\n\",Speaker\r\n\"A\tB\",\"A\r\nB\"\r\n"))
+	got, err := ReadRows(path, "calendar", ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Topic", "Speaker"}, {"A B", "A B"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadRows = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify turns text such as an event topic into a lowercase name that is
+// safe in file paths on every platform: runs of anything other than letters
+// and digits, including spaces, slashes, and punctuation, become a single
+// hyphen, and accents are dropped from common Latin letters. Slugs are cut
+// to maxSlugLength. It returns "untitled" when nothing usable is left.
+func Slugify(text string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		if folded, ok := accentFolds[r]; ok {
+			r = folded
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	if slug.Len() == 0 {
+		return "untitled"
+	}
+	result := slug.String()
+	if len(result) > maxSlugLength {
+		result = strings.TrimRight(result[:maxSlugLength], "-")
+	}
+	return result
+}
+
+// maxSlugLength keeps directory names readable and well under path limits.
+const maxSlugLength = 60
+
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
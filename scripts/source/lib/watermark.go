@@ -0,0 +1,43 @@
+package lib
+
+import (
+	"math"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// watermarkAlpha keeps the watermark faint enough to read the certificate
+// through it.
+const watermarkAlpha = 0.15
+
+// drawWatermark sets text, such as "REISSUED", diagonally across the first
+// page from the lower left to the upper right, sized to span most of the
+// diagonal. It is drawn before the design so the certificate text lies on
+// top of it.
+func drawWatermark(pdf *gofpdf.Fpdf, config CertificateConfig, text string) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	diagonal := math.Hypot(pageWidth, pageHeight)
+	angle := math.Atan2(pageHeight, pageWidth) * 180 / math.Pi
+	text = translator(pdf, config)(text)
+
+	// Scale from a trial size so the text covers 80% of the diagonal, but
+	// don't let a short word grow taller than a third of the page
+	size := 100.0
+	setFont(pdf, config, "B", size)
+	if width := pdf.GetStringWidth(text); width > 0 {
+		size = math.Min(size*diagonal*0.8/width, pageHeight/3/25.4*72)
+	}
+	setFont(pdf, config, "B", size)
+	width := pdf.GetStringWidth(text)
+	_, lineHeight := pdf.GetFontSize()
+
+	centerX, centerY := pageWidth/2, pageHeight/2
+	pdf.TransformBegin()
+	pdf.TransformRotate(angle, centerX, centerY)
+	pdf.SetAlpha(watermarkAlpha, "Normal")
+	pdf.SetTextColor(128, 128, 128)
+	pdf.SetXY(centerX-width/2, centerY-lineHeight/2)
+	pdf.CellFormat(width, lineHeight, text, "", 0, "C", false, 0, "")
+	pdf.SetAlpha(1, "Normal")
+	pdf.TransformEnd()
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// trimBio collapses the bio's whitespace, so a bio pasted with line breaks
+// stays inside its paragraph, and cuts it to at most maxWords words with a
+// trailing "...". maxWords 0 means no limit. A warning goes to stderr when
+// words are dropped so a long bio isn't shortened unnoticed.
+func trimBio(bio string, maxWords int) string {
+	words := strings.Fields(bio)
+	if maxWords == 0 || len(words) <= maxWords {
+		return strings.Join(words, " ")
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: speaker bio is %d words; trimmed to %d (see -bio-max-words)\n", len(words), maxWords)
+	trimmed := strings.Join(words[:maxWords], " ")
+	return strings.TrimRight(trimmed, ".,;:!?-") + "..."
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// pathList is a flag that may be repeated, collecting one path per use.
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *pathList) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
+
+// readCalendars reads the spreadsheet and any -calendar files and merges
+// their events. An event in more than one file (same date and topic) is
+// kept once, from the first file listed, with a warning. skipped totals the
+// events readSpreadsheet skipped across the files.
+func readCalendars(paths []string, opts lib.ReadOptions) (merged []Event, skipped int, err error) {
+	seen := make(map[string]string) // event key -> file it came from
+	for _, path := range paths {
+		events, n, err := readSpreadsheet(path, opts)
+		if err != nil {
+			if len(paths) > 1 {
+				return nil, 0, fmt.Errorf("%s: %w", path, err)
+			}
+			return nil, 0, err
+		}
+		skipped += n
+		for _, event := range events {
+			key := event.Date.Format("2006-01-02") + "|" + strings.ToLower(strings.Join(strings.Fields(event.Topic), " "))
+			if first, ok := seen[key]; ok {
+				fmt.Fprintf(os.Stderr, "Warning: %q on %s is in both %s and %s; using the one from %s\n",
+					event.Topic, event.Date.Format("2006-01-02"), first, path, first)
+				continue
+			}
+			seen[key] = path
+			merged = append(merged, event)
+		}
+	}
+	return merged, skipped, nil
+}
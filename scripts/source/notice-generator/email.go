@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/gomail.v2"
+)
+
+// mailAccount is the Gmail account -email-to sends from. Like
+// certificate-mailer's, it is read from GMAIL_EMAIL and GMAIL_APP_PASSWORD
+// in ../.env, with variables already set in the environment winning.
+type mailAccount struct {
+	Email       string
+	AppPassword string
+	Host        string
+	Port        int
+}
+
+func loadMailAccount() (*mailAccount, error) {
+	if err := godotenv.Load("../.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading ../.env: %v", err)
+	}
+	account := &mailAccount{
+		Email:       os.Getenv("GMAIL_EMAIL"),
+		AppPassword: os.Getenv("GMAIL_APP_PASSWORD"),
+		Host:        "smtp.gmail.com",
+		Port:        587,
+	}
+	if account.Email == "" || account.AppPassword == "" {
+		return nil, fmt.Errorf("GMAIL_EMAIL and GMAIL_APP_PASSWORD must be set in ../.env or the environment")
+	}
+	return account, nil
+}
+
+// sendNoticeEmail emails the rendered notice to every address in to, as
+// HTML for the html format and plain text otherwise, with the given files
+// attached.
+func sendNoticeEmail(account *mailAccount, to []string, event *Event, format string, body []byte, attachments []string) error {
+	contentType := "text/plain"
+	if format == "html" {
+		contentType = "text/html"
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", account.Email)
+	m.SetHeader("To", to...)
+	m.SetHeader("Subject", fmt.Sprintf("Little Rock Engineers Club: %s on %s", event.Topic, event.Date.Format("January 2")))
+	m.SetBody(contentType, string(body))
+	for _, path := range attachments {
+		m.Attach(path)
+	}
+
+	d := gomail.NewDialer(account.Host, account.Port, account.Email, account.AppPassword)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("sending notice email: %v", err)
+	}
+	return nil
+}
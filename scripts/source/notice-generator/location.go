@@ -0,0 +1,47 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// streetNumber matches the start of a street address such as "1200 President
+// Clinton Ave" or "500A Main St".
+var streetNumber = regexp.MustCompile(`^\d+[A-Za-z]?\s+\S`)
+
+// parseLocation splits a free-text location into venue, room, and street
+// address when it follows the "Venue, Room, 123 Street..." pattern:
+//
+//	"Clinton Library, Great Hall, 1200 President Clinton Ave"
+//	  -> "Clinton Library", "Great Hall", "1200 President Clinton Ave"
+//
+// Everything from the first comma-separated part that starts with a street
+// number onward is the address; the first part before it is the venue and any
+// parts in between are the room. If no street number is found, the whole
+// string is returned as the venue.
+func parseLocation(location string) (venue, room, address string) {
+	location = strings.TrimSpace(location)
+
+	var parts []string
+	for _, part := range strings.Split(location, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	for i, part := range parts {
+		if i == 0 || !streetNumber.MatchString(part) {
+			continue
+		}
+		venue = parts[0]
+		room = strings.Join(parts[1:i], ", ")
+		address = strings.Join(parts[i:], ", ")
+		return venue, room, address
+	}
+
+	// A location that is only an address has no venue name
+	if len(parts) > 0 && streetNumber.MatchString(parts[0]) {
+		return "", "", strings.Join(parts, ", ")
+	}
+	return location, "", ""
+}
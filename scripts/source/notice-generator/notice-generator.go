@@ -1,30 +1,95 @@
 package main
 
 import (
-	"encoding/csv"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"net/mail"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"text/template"
 	"time"
 
-	"github.com/xuri/excelize/v2"
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+	"gopkg.in/yaml.v3"
 )
 
 const noticeTemplate = `Dear Friends and Engineers,
 
-We're pleased to invite you to the next meeting of the Little Rock Engineers Club for 2025-2026, to be held at {{.Location}} at {{.Time}}. {{.LunchMessage}} Members are welcome to arrive 15 minutes early to enjoy lunch and informal networking with fellow professionals before we begin. We're excited to host guest speaker {{.Speaker}}. {{if .Bio}}{{.Bio}} {{end}}Our topic will be {{.Topic}}.
+{{with .Recap}}Thank you to everyone who joined us on {{.Date}}, when {{.Speaker}} spoke on {{.Topic}}.
+
+{{end}}We're pleased to invite you to the next meeting of the Little Rock Engineers Club for {{.Season}}, to be held at {{.Location}} at {{.Time}}. {{.LunchMessage}} {{if .EarlyMinutes}}Members are welcome to arrive {{.EarlyMinutes}} minutes early to enjoy lunch and informal networking with fellow professionals before we begin. {{end}}We're excited to host guest speaker {{.Speaker}}. {{if .Bio}}{{.Bio}} {{end}}Our topic will be {{.Topic}}.{{if .Capacity}} Seating is limited to {{.Capacity}}{{if .Registered}}, and {{.Registered}} have already registered{{end}}.{{end}}
 Meeting Details:
 
     Location: {{.Location}}
-    Time: {{.Time}} (Arrive 15 minutes prior for lunch and networking)
+    Time: {{.Time}}{{if .EarlyMinutes}} (Arrive {{.EarlyMinutes}} minutes prior for lunch and networking){{end}}
     Speakers: {{.Speaker}}
 
-We look forward to seeing you there and taking part in a great season of learning and collaboration.
-
-Best regards,`
+{{if .Agenda}}The meeting agenda ({{.Agenda}}) {{if .AgendaAttached}}is attached{{else}}will be sent separately{{end}}.
+
+{{end}}We look forward to seeing you there and taking part in a great season of learning and collaboration.
+
+Best regards,{{with .Signer}}
+{{.}}{{end}}{{with .Website}}
+{{.}}{{end}}{{with .ContactEmail}}
+{{.}}{{end}}`
+
+const markdownNoticeTemplate = `Dear Friends and Engineers,
+
+{{with .Recap}}Thank you to everyone who joined us on {{.Date}}, when **{{.Speaker}}** spoke on *{{.Topic}}*.
+
+{{end}}We're pleased to invite you to the next meeting of the Little Rock Engineers Club for {{.Season}}, to be held at {{.Location}} at {{.Time}}. {{.LunchMessage}} {{if .EarlyMinutes}}Members are welcome to arrive {{.EarlyMinutes}} minutes early to enjoy lunch and informal networking with fellow professionals before we begin. {{end}}We're excited to host guest speaker **{{.Speaker}}**. {{if .Bio}}{{.Bio}} {{end}}Our topic will be *{{.Topic}}*.{{if .Capacity}} Seating is limited to {{.Capacity}}{{if .Registered}}, and {{.Registered}} have already registered{{end}}.{{end}}
+
+**Meeting Details:**
+
+- **Location:** {{.Location}}
+- **Time:** {{.Time}}{{if .EarlyMinutes}} (Arrive {{.EarlyMinutes}} minutes prior for lunch and networking){{end}}
+- **Speakers:** {{.Speaker}}
+
+{{if .Agenda}}The meeting agenda ({{.Agenda}}) {{if .AgendaAttached}}is attached{{else}}will be sent separately{{end}}.
+
+{{end}}We look forward to seeing you there and taking part in a great season of learning and collaboration.
+
+Best regards,{{with .Signer}}\
+{{.}}{{end}}{{with .Website}}\
+<{{.}}>{{end}}{{with .ContactEmail}}\
+<{{.}}>{{end}}
+`
+
+const htmlNoticeTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Georgia, serif; max-width: 640px;">
+<p>Dear Friends and Engineers,</p>
+{{with .Recap}}<p>Thank you to everyone who joined us on {{.Date}}, when {{.Speaker}} spoke on {{.Topic}}.</p>
+{{end}}<p>We're pleased to invite you to the next meeting of the Little Rock Engineers Club for {{.Season}}, to be held at {{.Location}} at {{.Time}}. {{.LunchMessage}} {{if .EarlyMinutes}}Members are welcome to arrive {{.EarlyMinutes}} minutes early to enjoy lunch and informal networking with fellow professionals before we begin. {{end}}We're excited to host guest speaker {{.Speaker}}.</p>
+{{if or .Bio .SpeakerPhoto}}<table><tr>
+{{if .SpeakerPhoto}}<td style="vertical-align: top; padding-right: 12px;"><img src="{{safeURL .SpeakerPhoto}}" alt="{{.Speaker}}" width="120"></td>{{end}}
+<td style="vertical-align: top;">{{.Bio}}</td>
+</tr></table>
+{{end}}<p>Our topic will be {{.Topic}}.{{if .Capacity}} Seating is limited to {{.Capacity}}{{if .Registered}}, and {{.Registered}} have already registered{{end}}.{{end}}</p>
+<p><strong>Meeting Details:</strong></p>
+<ul>
+<li>Location: {{.Location}}</li>
+<li>Time: {{.Time}}{{if .EarlyMinutes}} (Arrive {{.EarlyMinutes}} minutes prior for lunch and networking){{end}}</li>
+<li>Speakers: {{.Speaker}}</li>
+</ul>
+{{if .Agenda}}<p>The meeting agenda ({{.Agenda}}) {{if .AgendaAttached}}is attached{{else}}will be sent separately{{end}}.</p>
+{{end}}<p>We look forward to seeing you there and taking part in a great season of learning and collaboration.</p>
+<p>Best regards,{{with .Signer}}<br>
+{{.}}{{end}}{{with .Website}}<br>
+<a href="{{.}}">{{.}}</a>{{end}}{{with .ContactEmail}}<br>
+<a href="mailto:{{.}}">{{.}}</a>{{end}}</p>
+</body>
+</html>
+`
 
 type Event struct {
 	Date     time.Time
@@ -32,6 +97,12 @@ type Event struct {
 	Speaker  string
 	Location string
 	Time     string
+
+	// Venue, Room, and Address are parsed from Location for structured
+	// output. Location itself is still what the notice renders.
+	Venue   string
+	Room    string
+	Address string
 }
 
 type TemplateData struct {
@@ -42,251 +113,719 @@ type TemplateData struct {
 	Time         string
 	Bio          string
 	LunchMessage string
+	SpeakerPhoto string
+	EarlyMinutes int
+	Season       string
+	// Capacity is the venue's seat limit, 0 if not given; Registered is how
+	// many have signed up, 0 if unknown
+	Capacity   int
+	Registered int
+	// Signer, Website, and ContactEmail make up the signature block after
+	// "Best regards,"; empty ones are left out
+	Signer       string
+	Website      string
+	ContactEmail string
+	// Recap is the previous meeting when -with-recap is set and one exists
+	Recap *Recap
+	// Agenda is the file name of the -agenda PDF, empty if none.
+	// AgendaAttached is set when it goes out with the -email-to email;
+	// otherwise the notice says it will be sent separately.
+	Agenda         string
+	AgendaAttached bool
+	// Extra holds the -template-data values for custom templates
+	Extra map[string]any
+}
+
+// noticeRenderer is satisfied by both text/template and html/template.
+type noticeRenderer interface {
+	Execute(w io.Writer, data any) error
 }
 
 func main() {
+	// Bad flags exit 1 rather than flag's default of 2, which means partial failure here
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+
 	var bio string
+	var bioMaxWords int
 	var lunchProvided bool
 	var output string
+	var outputDir, nameTemplate string
 	var templatePath string
+	var format string
+	var speakerPhoto string
+	var overridesPath string
+	var speakerOrg string
+	var speakerEmail string
+	var listEvents bool
+	var earlyMinutes int
+	var capacity, registered int
+	var season string
+	var watch bool
+	var serve string
+	var calendars pathList
+	var withRecap bool
+	var templateData string
+	var signer, website, contactEmail string
+	var agenda string
+	var emailTo string
+	var readOpts lib.ReadOptions
 
 	flag.StringVar(&bio, "bio", "", "Speaker bio (optional)")
+	flag.IntVar(&bioMaxWords, "bio-max-words", 120, "Trim longer speaker bios to this many words with a trailing \"...\" (0 for no limit)")
 	flag.BoolVar(&lunchProvided, "lunch-provided", false, "Use 'Lunch will be provided.' instead of default message")
+	flag.IntVar(&earlyMinutes, "early-minutes", 15, "Minutes early members may arrive for lunch and networking (0 omits it)")
+	flag.IntVar(&capacity, "capacity", 0, "Venue seat limit, shown as \"Seating is limited to N.\" (0 omits it)")
+	flag.IntVar(&registered, "registered", 0, "Number registered so far, shown with -capacity (0 omits it)")
+	flag.StringVar(&season, "season", "", "Club season shown in the notice, e.g. 2025-2026 (default: derived from the event date)")
 	flag.StringVar(&output, "output", "notices.txt", "Output file path")
 	flag.StringVar(&output, "o", "notices.txt", "Output file path (short form)")
-	flag.StringVar(&templatePath, "template", "notice_template", "Template file path (ignored - using embedded template)")
+	flag.StringVar(&outputDir, "output-dir", "", "Write each event's notice to its own file in this directory instead of -output, for an archive")
+	flag.StringVar(&nameTemplate, "name-template", defaultNameTemplate, "Go template over the event (.Date, .Topic, .Speaker, .Location, .Time) naming files in -output-dir")
+	flag.StringVar(&templatePath, "template", "", "Custom Go template file for the notice (default: the built-in template for -format)")
+	flag.StringVar(&templateData, "template-data", "", "JSON object, inline or in a file, whose keys templates can use as .Extra.<Key>")
+	flag.StringVar(&readOpts.Encoding, "encoding", "utf-8", "Character encoding of a CSV spreadsheet: "+strings.Join(lib.CSVEncodings(), ", "))
+	flag.StringVar(&format, "format", "text", "Output format: text, markdown, or html")
+	flag.Var(&calendars, "calendar", "Another calendar whose events are merged with SPREADSHEET's, e.g. joint meetings; repeat for more")
+	flag.StringVar(&overridesPath, "overrides", "", "Per-event overrides file (default: SPREADSHEET.overrides.yaml if present)")
+	flag.StringVar(&speakerOrg, "speaker-org", "", "Speaker organization for the vCard (optional)")
+	flag.StringVar(&speakerEmail, "speaker-email", "", "Speaker email for the vCard (optional)")
+	flag.BoolVar(&readOpts.Strict, "strict", false, "Fail instead of skipping spreadsheet rows that are missing columns or have unparseable dates")
+	flag.BoolVar(&listEvents, "list-events", false, "List all parsed events, mark the one that would be used, and exit")
+	flag.BoolVar(&withRecap, "with-recap", false, "Open the notice with a recap of the most recent past meeting, if there is one")
+	flag.BoolVar(&watch, "watch", false, "Keep running and regenerate the notice whenever the spreadsheet is saved (Ctrl-C to stop)")
+	flag.StringVar(&signer, "signer", "Little Rock Engineers Club", "Name signed under \"Best regards,\" (empty to leave it off)")
+	flag.StringVar(&website, "website", "", "Club website added to the signature (optional)")
+	flag.StringVar(&contactEmail, "contact-email", "", "Contact email added to the signature (optional)")
+	flag.StringVar(&agenda, "agenda", "", "Agenda PDF attached to the -email-to email and mentioned in the notice")
+	flag.StringVar(&emailTo, "email-to", "", "Email the notice to these comma-separated addresses, with the agenda and speaker vCard attached; sent from GMAIL_EMAIL in ../.env")
+	flag.StringVar(&serve, "serve", "", "Serve a live preview of the notice at this address, e.g. :8080, re-rendered from the spreadsheet on every request")
+	flag.StringVar(&speakerPhoto, "speaker-photo", "", "Speaker photo path or URL shown next to the bio (html format only)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTIONS] SPREADSHEET\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), `
+Exit codes:
+  0  the notice was generated and every spreadsheet row was read
+  1  fatal error; no notice was written
+  2  the notice was generated but some rows were skipped or outputs failed
+`)
+	}
 
-	flag.Parse()
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
 
 	if flag.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] SPREADSHEET\n", os.Args[0])
-		flag.PrintDefaults()
+		flag.Usage()
 		os.Exit(1)
 	}
 
 	spreadsheet := flag.Arg(0)
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	calendars = append(pathList{spreadsheet}, calendars...)
 
-	lunchMessage := "Feel free to bring your own lunch."
-	if lunchProvided {
-		lunchMessage = "Lunch will be provided."
+	if earlyMinutes < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -early-minutes %d: must not be negative\n", earlyMinutes)
+		os.Exit(1)
 	}
 
-	events, err := readSpreadsheet(spreadsheet)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading spreadsheet: %v\n", err)
+	if capacity < 0 || registered < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -capacity or -registered: must not be negative\n")
 		os.Exit(1)
 	}
 
-	now := time.Now()
-	var closestEvent *Event
-	var minDiff time.Duration
+	if bioMaxWords < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -bio-max-words %d: must not be negative\n", bioMaxWords)
+		os.Exit(1)
+	}
 
-	for _, event := range events {
-		if event.Date.After(now) {
-			diff := event.Date.Sub(now)
-			if closestEvent == nil || diff < minDiff {
-				closestEvent = &event
-				minDiff = diff
-			}
-		}
+	if err := lib.ValidateEncoding(readOpts.Encoding); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -encoding: %v\n", err)
+		os.Exit(1)
 	}
 
-	if closestEvent == nil {
-		fmt.Println("No future events found in the spreadsheet.")
-		return
+	if format != "text" && format != "markdown" && format != "html" {
+		fmt.Fprintf(os.Stderr, "Invalid -format %q: must be text, markdown, or html\n", format)
+		os.Exit(1)
 	}
 
-	tmpl, err := template.New("notice").Parse(noticeTemplate)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing template: %v\n", err)
+	var namer *template.Template
+	if outputDir != "" {
+		if setFlags["output"] || setFlags["o"] {
+			fmt.Fprintf(os.Stderr, "-output and -output-dir can't be used together\n")
+			os.Exit(1)
+		}
+		var err error
+		namer, err = parseNameTemplate(nameTemplate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -name-template: %v\n", err)
+			os.Exit(1)
+		}
+	} else if setFlags["name-template"] {
+		fmt.Fprintf(os.Stderr, "-name-template requires -output-dir\n")
 		os.Exit(1)
 	}
 
-	data := TemplateData{
-		Date:         closestEvent.Date.Format("2006-01-02"),
-		Topic:        closestEvent.Topic,
-		Speaker:      closestEvent.Speaker,
-		Location:     closestEvent.Location,
-		Time:         closestEvent.Time,
-		Bio:          bio,
-		LunchMessage: lunchMessage,
+	// Resolve the photo up front so a bad path fails before any output is written
+	var photoSrc string
+	if speakerPhoto != "" && (format == "html" || serve != "") {
+		var err error
+		photoSrc, err = resolvePhoto(speakerPhoto)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading speaker photo: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	file, err := os.Create(output)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
-		os.Exit(1)
+	// Check the recipients and credentials up front, so a typo fails before
+	// any output is written
+	var recipients []string
+	var account *mailAccount
+	if emailTo != "" {
+		if watch || serve != "" {
+			fmt.Fprintf(os.Stderr, "-email-to can't be used with -watch or -serve, which would send on every change\n")
+			os.Exit(1)
+		}
+		addresses, err := mail.ParseAddressList(emailTo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -email-to: %v\n", err)
+			os.Exit(1)
+		}
+		for _, address := range addresses {
+			recipients = append(recipients, address.Address)
+		}
+		account, err = loadMailAccount()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading email credentials: %v\n", err)
+			os.Exit(1)
+		}
 	}
-	defer file.Close()
 
-	err = tmpl.Execute(file, data)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error executing template: %v\n", err)
-		os.Exit(1)
+	// Check the agenda up front too. Without -email-to there is nothing to
+	// attach it to, so the notice says it will be sent separately.
+	if agenda != "" {
+		info, err := os.Stat(agenda)
+		if err == nil && !info.Mode().IsRegular() {
+			err = fmt.Errorf("%s is not a file", agenda)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -agenda: %v\n", err)
+			os.Exit(1)
+		}
+		if emailTo == "" {
+			fmt.Fprintf(os.Stderr, "Warning: -agenda %s isn't attached without -email-to; the notice says it will be sent separately\n", agenda)
+		}
 	}
 
-	fmt.Printf("Generated notice for %s event and saved to %s\n", closestEvent.Date.Format("2006-01-02"), output)
-}
+	var extra map[string]any
+	if templateData != "" {
+		var err error
+		extra, err = readTemplateData(templateData)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading -template-data: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-func readSpreadsheet(filename string) ([]Event, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
+	opts := noticeOptions{
+		Spreadsheet:   spreadsheet,
+		Calendars:     calendars,
+		ReadOptions:   readOpts,
+		Output:        output,
+		OutputDir:     outputDir,
+		NameTemplate:  namer,
+		Format:        format,
+		OverridesPath: overridesPath,
+		Bio:           bio,
+		BioMaxWords:   bioMaxWords,
+		LunchProvided: lunchProvided,
+		EarlyMinutes:  earlyMinutes,
+		Capacity:      capacity,
+		Registered:    registered,
+		Season:        season,
+		PhotoSrc:      photoSrc,
+		SpeakerOrg:    speakerOrg,
+		SpeakerEmail:  speakerEmail,
+		WithRecap:     withRecap,
+		Signer:        signer,
+		Website:       website,
+		ContactEmail:  contactEmail,
+		Agenda:        agenda,
+		EmailTo:       recipients,
+		Mail:          account,
+		TemplatePath:  templatePath,
+		Extra:         extra,
+		SetFlags:      setFlags,
+	}
 
-	if ext == ".xlsx" || ext == ".xls" {
-		return readExcel(filename)
+	if listEvents {
+		events, _, err := readCalendars(calendars, readOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading spreadsheet: %v\n", err)
+			os.Exit(1)
+		}
+		printEventList(events, findClosestEvent(events, time.Now()))
+		return
 	}
-	return readCSV(filename)
-}
 
-func readCSV(filename string) ([]Event, error) {
-	file, err := os.Open(filename)
+	if serve != "" {
+		if watch {
+			fmt.Fprintf(os.Stderr, "-serve and -watch can't be used together; -serve already re-renders on every request\n")
+			os.Exit(1)
+		}
+		if err := serveNotice(serve, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving preview: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	partial, err := generateNotice(opts)
+	if watch {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		}
+		if err := watchSpreadsheet(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error watching spreadsheet: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if err != nil {
-		return nil, err
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+	if partial {
+		os.Exit(2)
 	}
-	defer file.Close()
+}
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+// noticeOptions is everything generateNotice needs, gathered from flags so a
+// watch loop can regenerate with the same settings. SetFlags records which
+// flags were given explicitly, since those win over per-event overrides.
+type noticeOptions struct {
+	Spreadsheet   string
+	Calendars     []string // Spreadsheet first, then any -calendar files
+	ReadOptions   lib.ReadOptions
+	Output        string
+	OutputDir     string
+	NameTemplate  *template.Template // names files in OutputDir
+	Format        string
+	OverridesPath string
+	Bio           string
+	BioMaxWords   int
+	LunchProvided bool
+	EarlyMinutes  int
+	Capacity      int
+	Registered    int
+	Season        string
+	PhotoSrc      string
+	SpeakerOrg    string
+	SpeakerEmail  string
+	WithRecap     bool
+	Signer        string
+	Website       string
+	ContactEmail  string
+	Agenda        string
+	EmailTo       []string     // empty unless the notice is emailed
+	Mail          *mailAccount // sends to EmailTo
+	TemplatePath  string
+	Extra         map[string]any
+	SetFlags      map[string]bool
+}
+
+// generateNotice reads the spreadsheet and writes the notice for the next
+// event, plus the speaker vCard when there are contact details, and emails
+// them with the agenda to EmailTo. It returns an error when no notice could
+// be written, and partial when the notice was written but rows were skipped
+// or the vCard or email failed.
+func generateNotice(opts noticeOptions) (partial bool, err error) {
+	var buf bytes.Buffer
+	notice, err := renderNotice(opts, &buf)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	if notice == nil {
+		fmt.Println("No future events found in the spreadsheet.")
+		return false, nil
 	}
 
-	if len(records) < 2 {
-		return nil, fmt.Errorf("spreadsheet must have header and at least one data row")
+	output := opts.Output
+	if opts.OutputDir != "" {
+		output, err = noticePath(opts, notice.Event)
+		if err != nil {
+			return false, err
+		}
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return false, fmt.Errorf("writing output file: %v", err)
 	}
 
-	header := records[0]
-	dateIdx, topicIdx, speakerIdx, locationIdx, timeIdx := -1, -1, -1, -1, -1
+	fmt.Printf("Generated notice for %s event and saved to %s\n", notice.Event.Date.Format("2006-01-02"), output)
 
-	for i, col := range header {
-		switch strings.ToLower(strings.TrimSpace(col)) {
-		case "date":
-			dateIdx = i
-		case "topic":
-			topicIdx = i
-		case "speaker":
-			speakerIdx = i
-		case "location":
-			locationIdx = i
-		case "time":
-			timeIdx = i
+	// Write the speaker's vCard next to the notice for attaching to the email.
+	// Without any contact details it would only repeat the name, so skip it.
+	var attachments []string
+	if opts.Agenda != "" {
+		attachments = append(attachments, opts.Agenda)
+	}
+	if notice.SpeakerOrg != "" || notice.SpeakerEmail != "" {
+		vcardPath := strings.TrimSuffix(output, filepath.Ext(output)) + ".vcf"
+		card := VCard{Name: notice.Event.Speaker, Organization: notice.SpeakerOrg, Email: notice.SpeakerEmail}
+		if err := os.WriteFile(vcardPath, []byte(card.String()), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing vCard: %v\n", err)
+			partial = true
+		} else {
+			fmt.Printf("Saved speaker vCard to %s\n", vcardPath)
+			attachments = append(attachments, vcardPath)
 		}
 	}
 
-	if dateIdx == -1 || topicIdx == -1 || speakerIdx == -1 || locationIdx == -1 || timeIdx == -1 {
-		return nil, fmt.Errorf("spreadsheet must have columns: date, topic, speaker, location, time")
+	if len(opts.EmailTo) > 0 {
+		if err := sendNoticeEmail(opts.Mail, opts.EmailTo, notice.Event, opts.Format, buf.Bytes(), attachments); err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			partial = true
+		} else {
+			fmt.Printf("Emailed the notice to %s\n", strings.Join(opts.EmailTo, ", "))
+		}
 	}
 
-	var events []Event
-	for _, row := range records[1:] {
-		if len(row) <= dateIdx || len(row) <= topicIdx || len(row) <= speakerIdx ||
-		   len(row) <= locationIdx || len(row) <= timeIdx {
-			continue
+	return partial || notice.Skipped > 0, nil
+}
+
+// renderedNotice describes the notice renderNotice wrote: the event it is
+// for, the speaker contact details, after overrides, for the vCard, and how
+// many events were skipped reading the calendars.
+type renderedNotice struct {
+	Event        *Event
+	SpeakerOrg   string
+	SpeakerEmail string
+	Skipped      int
+}
+
+// renderNotice reads the spreadsheet and renders the notice for the next
+// event in opts.Format to w. It returns nil without writing anything when
+// there is no future event.
+func renderNotice(opts noticeOptions, w io.Writer) (*renderedNotice, error) {
+	events, skipped, err := readCalendars(opts.Calendars, opts.ReadOptions)
+	if err != nil {
+		return nil, fmt.Errorf("reading spreadsheet: %w", err)
+	}
+	if opts.ReadOptions.Strict && skipped > 0 {
+		return nil, fmt.Errorf("reading spreadsheet: %d event(s) skipped (-strict)", skipped)
+	}
+
+	now := time.Now()
+	closestEvent := findClosestEvent(events, now)
+	if closestEvent == nil && opts.ReadOptions.Strict {
+		return nil, fmt.Errorf("%w in the future in the spreadsheet (-strict)", lib.ErrNoEvents)
+	}
+	if closestEvent == nil {
+		return nil, nil
+	}
+
+	bio := opts.Bio
+	lunchProvided := opts.LunchProvided
+	speakerOrg := opts.SpeakerOrg
+	speakerEmail := opts.SpeakerEmail
+	capacity := opts.Capacity
+	registered := opts.Registered
+
+	// Merge sidecar overrides for this event; explicit flags still win
+	overridesPath := opts.OverridesPath
+	if overridesPath == "" {
+		overridesPath = findOverridesFile(opts.Spreadsheet)
+	}
+	if overridesPath != "" {
+		overrides, err := readOverrides(overridesPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading overrides: %v", err)
+		}
+
+		if override, ok := overrides[closestEvent.Date.Format("2006-01-02")]; ok {
+			if override.Bio != nil && !opts.SetFlags["bio"] {
+				bio = *override.Bio
+			}
+			if override.LunchProvided != nil && !opts.SetFlags["lunch-provided"] {
+				lunchProvided = *override.LunchProvided
+			}
+			if override.Location != nil {
+				closestEvent.Location = *override.Location
+				closestEvent.Venue, closestEvent.Room, closestEvent.Address = parseLocation(closestEvent.Location)
+			}
+			if override.SpeakerOrg != nil && !opts.SetFlags["speaker-org"] {
+				speakerOrg = *override.SpeakerOrg
+			}
+			if override.SpeakerEmail != nil && !opts.SetFlags["speaker-email"] {
+				speakerEmail = *override.SpeakerEmail
+			}
+			if override.Capacity != nil && !opts.SetFlags["capacity"] {
+				capacity = *override.Capacity
+			}
+			if override.Registered != nil && !opts.SetFlags["registered"] {
+				registered = *override.Registered
+			}
 		}
+	}
+
+	bio = trimBio(bio, opts.BioMaxWords)
 
-		date, err := parseDate(row[dateIdx])
+	lunchMessage := "Feel free to bring your own lunch."
+	if lunchProvided {
+		lunchMessage = "Lunch will be provided."
+	}
+
+	// A custom template replaces the built-in one. It is read on every run, so
+	// under -watch template edits apply from the next spreadsheet save
+	templateText := map[string]string{
+		"html":     htmlNoticeTemplate,
+		"markdown": markdownNoticeTemplate,
+		"text":     noticeTemplate,
+	}[opts.Format]
+	if opts.TemplatePath != "" {
+		custom, err := os.ReadFile(opts.TemplatePath)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("reading template: %v", err)
 		}
+		templateText = string(custom)
+	}
 
-		events = append(events, Event{
-			Date:     date,
-			Topic:    row[topicIdx],
-			Speaker:  row[speakerIdx],
-			Location: row[locationIdx],
-			Time:     row[timeIdx],
-		})
+	var tmpl noticeRenderer
+	switch opts.Format {
+	case "html":
+		tmpl, err = htmltemplate.New("notice").Funcs(htmltemplate.FuncMap{
+			"safeURL": func(s string) htmltemplate.URL { return htmltemplate.URL(s) },
+		}).Parse(templateText)
+	default:
+		tmpl, err = template.New("notice").Parse(templateText)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %v", err)
+	}
+
+	season := opts.Season
+	if season == "" {
+		season = seasonFor(closestEvent.Date)
+	}
+
+	data := TemplateData{
+		Date:         closestEvent.Date.Format("2006-01-02"),
+		Topic:        closestEvent.Topic,
+		Speaker:      closestEvent.Speaker,
+		Location:     closestEvent.Location,
+		Time:         closestEvent.Time,
+		Bio:          bio,
+		LunchMessage: lunchMessage,
+		SpeakerPhoto: opts.PhotoSrc,
+		EarlyMinutes: opts.EarlyMinutes,
+		Capacity:     capacity,
+		Registered:   registered,
+		Season:       season,
+		Signer:       opts.Signer,
+		Website:      opts.Website,
+		ContactEmail: opts.ContactEmail,
+		Extra:        opts.Extra,
+	}
+	if opts.Agenda != "" {
+		data.Agenda = filepath.Base(opts.Agenda)
+		data.AgendaAttached = len(opts.EmailTo) > 0
+	}
+	if opts.WithRecap {
+		data.Recap = findRecapEvent(events, now)
 	}
 
-	return events, nil
+	if err := tmpl.Execute(w, data); err != nil {
+		return nil, fmt.Errorf("executing template: %v", err)
+	}
+	return &renderedNotice{Event: closestEvent, SpeakerOrg: speakerOrg, SpeakerEmail: speakerEmail, Skipped: skipped}, nil
 }
 
-func readExcel(filename string) ([]Event, error) {
-	f, err := excelize.OpenFile(filename)
-	if err != nil {
-		return nil, err
+// resolvePhoto returns an image source for the HTML notice. URLs are used as
+// is; local files are embedded as a data URI so the notice is self-contained.
+func resolvePhoto(photo string) (string, error) {
+	if strings.HasPrefix(photo, "http://") || strings.HasPrefix(photo, "https://") {
+		return photo, nil
 	}
-	defer f.Close()
 
-	sheetName := f.GetSheetName(0)
-	rows, err := f.GetRows(sheetName)
+	data, err := os.ReadFile(photo)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	if len(rows) < 2 {
-		return nil, fmt.Errorf("spreadsheet must have header and at least one data row")
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("%s is not an image (detected %s)", photo, mimeType)
 	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
 
-	header := rows[0]
-	dateIdx, topicIdx, speakerIdx, locationIdx, timeIdx := -1, -1, -1, -1, -1
+// findClosestEvent returns the soonest event after now, or nil if there is none.
+func findClosestEvent(events []Event, now time.Time) *Event {
+	var closestEvent *Event
+	var minDiff time.Duration
 
-	for i, col := range header {
-		switch strings.ToLower(strings.TrimSpace(col)) {
-		case "date":
-			dateIdx = i
-		case "topic":
-			topicIdx = i
-		case "speaker":
-			speakerIdx = i
-		case "location":
-			locationIdx = i
-		case "time":
-			timeIdx = i
+	for _, event := range events {
+		if event.Date.After(now) {
+			diff := event.Date.Sub(now)
+			if closestEvent == nil || diff < minDiff {
+				closestEvent = &event
+				minDiff = diff
+			}
 		}
 	}
+	return closestEvent
+}
 
-	if dateIdx == -1 || topicIdx == -1 || speakerIdx == -1 || locationIdx == -1 || timeIdx == -1 {
-		return nil, fmt.Errorf("spreadsheet must have columns: date, topic, speaker, location, time")
+// seasonFor returns the club season an event falls in. Seasons start in
+// August, so a September 2025 meeting and a March 2026 meeting are both in
+// "2025-2026".
+func seasonFor(date time.Time) string {
+	year := date.Year()
+	if date.Month() < time.August {
+		year--
 	}
+	return fmt.Sprintf("%d-%d", year, year+1)
+}
 
-	var events []Event
-	for _, row := range rows[1:] {
-		if len(row) <= dateIdx || len(row) <= topicIdx || len(row) <= speakerIdx ||
-		   len(row) <= locationIdx || len(row) <= timeIdx {
-			continue
+// printEventList prints every event in date order, marking the selected one.
+func printEventList(events []Event, selected *Event) {
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tDATE\tTOPIC\tSPEAKER")
+	for _, event := range sorted {
+		marker := ""
+		if selected != nil && event.Date.Equal(selected.Date) && event.Topic == selected.Topic && event.Speaker == selected.Speaker {
+			marker = "*"
 		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, event.Date.Format("2006-01-02"), event.Topic, event.Speaker)
+	}
+	w.Flush()
+
+	if selected == nil {
+		fmt.Println("\nNo future events; nothing would be generated.")
+	} else {
+		fmt.Println("\n* closest future event, used for the notice")
+	}
+}
+
+// readSpreadsheet reads the events from a calendar: a JSON or YAML event
+// list, or a CSV or Excel spreadsheet read with lib.ReadCalendar. Events
+// whose date doesn't parse are skipped with a warning and counted in
+// skipped.
+func readSpreadsheet(filename string, opts lib.ReadOptions) (events []Event, skipped int, err error) {
+	var infos []lib.EventInfo
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		infos, err = readJSON(filename)
+	case ".yaml", ".yml":
+		infos, err = readYAML(filename)
+	default:
+		infos, err = lib.ReadCalendar(filename, opts)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
 
-		date, err := parseDate(row[dateIdx])
+	for i, info := range infos {
+		date, err := lib.ParseFlexibleDate(strings.TrimSpace(info.Date))
 		if err != nil {
+			if warnSkippedEvent(fmt.Sprintf("event %d", i+1), info.Date, err) {
+				skipped++
+			}
 			continue
 		}
 
-		events = append(events, Event{
+		event := Event{
 			Date:     date,
-			Topic:    row[topicIdx],
-			Speaker:  row[speakerIdx],
-			Location: row[locationIdx],
-			Time:     row[timeIdx],
-		})
+			Topic:    cleanText(info.Topic),
+			Speaker:  cleanText(info.Speaker),
+			Location: cleanText(info.Location),
+			Time:     cleanText(info.Time),
+		}
+		event.Venue, event.Room, event.Address = parseLocation(event.Location)
+		events = append(events, event)
 	}
+	return events, skipped, nil
+}
+
+// cleanText collapses embedded newlines, tabs, and repeated spaces left by
+// copy-paste into single spaces, and trims the ends. Spreadsheet cells come
+// cleaned from lib; this does the same for JSON and YAML values.
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
 
-	return events, nil
+// eventRecord is the on-disk shape of an event in JSON and YAML input files.
+type eventRecord struct {
+	Date     string `json:"date" yaml:"date"`
+	Topic    string `json:"topic" yaml:"topic"`
+	Speaker  string `json:"speaker" yaml:"speaker"`
+	Location string `json:"location" yaml:"location"`
+	Time     string `json:"time" yaml:"time"`
 }
 
-func parseDate(dateStr string) (time.Time, error) {
-	formats := []string{
-		"2006-01-02",
-		"01/02/2006",
-		"1/2/2006",
-		"2006/01/02",
-		"02-Jan-2006",
-		"2-Jan-2006",
-		"Jan 2, 2006",
-		"January 2, 2006",
-		time.RFC3339,
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
-			return t, nil
+func recordsToEvents(records []eventRecord) []lib.EventInfo {
+	events := make([]lib.EventInfo, len(records))
+	for i, rec := range records {
+		events[i] = lib.EventInfo{
+			Date:     rec.Date,
+			Topic:    rec.Topic,
+			Speaker:  rec.Speaker,
+			Location: rec.Location,
+			Time:     rec.Time,
 		}
 	}
+	return events
+}
+
+func readJSON(filename string) ([]lib.EventInfo, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
 
-	excelEpoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
-	var days float64
-	if _, err := fmt.Sscanf(dateStr, "%f", &days); err == nil && days > 0 {
-		return excelEpoch.AddDate(0, 0, int(days)), nil
+	var records []eventRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %v", filename, err)
 	}
 
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
-}
\ No newline at end of file
+	return recordsToEvents(records), nil
+}
+
+// readYAML reads a YAML sequence of event mappings with the same keys as the
+// JSON input, e.g.
+//
+//	# calendar.yaml
+//	- date: 2025-10-08
+//	  topic: Bridge Inspection
+//	  speaker: Jane Doe
+func readYAML(filename string) ([]lib.EventInfo, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []eventRecord
+	if err := yaml.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %v", filename, err)
+	}
+
+	return recordsToEvents(records), nil
+}
+
+// warnSkippedEvent reports an event dropped because its date didn't parse,
+// returning whether it counts as skipped. Blank dates are padding rows and
+// are skipped quietly.
+func warnSkippedEvent(where string, dateStr string, err error) bool {
+	if strings.TrimSpace(dateStr) == "" {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", where, err)
+	return true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// defaultNameTemplate names each notice by its date and topic, e.g.
+// "2025-10-08 Bridge Inspection.txt".
+const defaultNameTemplate = `{{.Date.Format "2006-01-02"}} {{.Topic}}`
+
+// formatExtensions is added to an -output-dir filename that doesn't already
+// end in one of them.
+var formatExtensions = map[string]string{
+	"text":     ".txt",
+	"markdown": ".md",
+	"html":     ".html",
+}
+
+// parseNameTemplate parses -name-template and tries it on a sample event,
+// so a typo fails before anything is read or written.
+func parseNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, &Event{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// noticePath returns where the notice for event goes in -output-dir: the
+// name template's result with path separators and other characters that
+// aren't allowed in filenames replaced, plus the format's extension unless
+// the template already ended in a notice extension. A period elsewhere, as
+// in a topic like "St. Louis Bridge", doesn't count. The directory is
+// created if it doesn't exist.
+func noticePath(opts noticeOptions, event *Event) (string, error) {
+	var name bytes.Buffer
+	if err := opts.NameTemplate.Execute(&name, event); err != nil {
+		return "", fmt.Errorf("-name-template: %v", err)
+	}
+	base := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name.String()))
+	if base == "" || base == "." || base == ".." {
+		return "", fmt.Errorf("-name-template gave an empty filename for %q", event.Topic)
+	}
+	if !hasNoticeExtension(base) {
+		base += formatExtensions[opts.Format]
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return "", fmt.Errorf("creating -output-dir: %v", err)
+	}
+	return filepath.Join(opts.OutputDir, base), nil
+}
+
+// hasNoticeExtension reports whether name ends in one of formatExtensions.
+func hasNoticeExtension(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, known := range formatExtensions {
+		if ext == known {
+			return true
+		}
+	}
+	return false
+}
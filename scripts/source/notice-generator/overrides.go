@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+	"gopkg.in/yaml.v3"
+)
+
+// EventOverride holds sidecar values merged over a spreadsheet event. Nil
+// fields were not present in the file and leave the event unchanged.
+type EventOverride struct {
+	Bio           *string `json:"bio" yaml:"bio"`
+	LunchProvided *bool   `json:"lunch-provided" yaml:"lunch-provided"`
+	Location      *string `json:"location" yaml:"location"`
+	SpeakerOrg    *string `json:"speaker-org" yaml:"speaker-org"`
+	SpeakerEmail  *string `json:"speaker-email" yaml:"speaker-email"`
+	Capacity      *int    `json:"capacity" yaml:"capacity"`
+	Registered    *int    `json:"registered" yaml:"registered"`
+}
+
+// findOverridesFile looks for a sidecar next to the spreadsheet, e.g.
+// events.xlsx -> events.overrides.yaml. It returns "" when none exists.
+func findOverridesFile(spreadsheet string) string {
+	base := strings.TrimSuffix(spreadsheet, filepath.Ext(spreadsheet))
+	for _, ext := range []string{".overrides.yaml", ".overrides.yml", ".overrides.json"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// readOverrides reads a sidecar keyed by event date. Keys may use any format
+// accepted by lib.ParseFlexibleDate; the returned map is keyed by "2006-01-02".
+//
+// YAML sidecars use a two-level mapping:
+//
+//	2025-10-08:
+//	  bio: Jane Doe is a structural engineer...
+//	  lunch-provided: true
+//	  location: Main Library, Room 2
+//	  speaker-org: Acme Engineering
+//	  capacity: 60
+//	  registered: 42
+func readOverrides(filename string) (map[string]EventOverride, error) {
+	var raw map[string]EventOverride
+	var err error
+
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		raw, err = readJSONOverrides(filename)
+	} else {
+		raw, err = readYAMLOverrides(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]EventOverride)
+	for key, override := range raw {
+		date, err := lib.ParseFlexibleDate(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		if (override.Capacity != nil && *override.Capacity < 0) || (override.Registered != nil && *override.Registered < 0) {
+			return nil, fmt.Errorf("%s: %s: capacity and registered must not be negative", filename, key)
+		}
+		overrides[date.Format("2006-01-02")] = override
+	}
+	return overrides, nil
+}
+
+func readJSONOverrides(filename string) (map[string]EventOverride, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]EventOverride
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %v", filename, err)
+	}
+	return raw, nil
+}
+
+func readYAMLOverrides(filename string) (map[string]EventOverride, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]EventOverride
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %v", filename, err)
+	}
+	return raw, nil
+}
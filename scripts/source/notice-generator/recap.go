@@ -0,0 +1,37 @@
+package main
+
+import (
+	"time"
+
+	"github.com/samuel-kreimeyer/LREC/scripts/source/lib"
+)
+
+// Recap is the previous meeting, thanked at the top of the notice.
+type Recap struct {
+	Date    string
+	Topic   string
+	Speaker string
+}
+
+// findRecapEvent returns the most recent event before now, chosen the same
+// way the certificate mailer picks the event to certify, or nil if none has
+// happened yet.
+func findRecapEvent(events []Event, now time.Time) *Recap {
+	infos := make([]lib.EventInfo, len(events))
+	for i, event := range events {
+		infos[i] = lib.EventInfo{
+			Date:    event.Date.Format("2006-01-02"),
+			Topic:   event.Topic,
+			Speaker: event.Speaker,
+		}
+	}
+
+	past := lib.PastEvents(infos, now)
+	if len(past) == 0 {
+		return nil
+	}
+	lib.SortNewestFirst(past)
+
+	date, _ := time.Parse("2006-01-02", past[0].Date)
+	return &Recap{Date: date.Format("January 2"), Topic: past[0].Topic, Speaker: past[0].Speaker}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// previewFormats maps each -serve path to the format rendered there and the
+// content type it is served with.
+var previewFormats = map[string]struct{ format, contentType string }{
+	"/":         {"html", "text/html; charset=utf-8"},
+	"/text":     {"text", "text/plain; charset=utf-8"},
+	"/markdown": {"markdown", "text/plain; charset=utf-8"},
+}
+
+// serveNotice serves a live preview of the notice at addr: HTML at /, plain
+// text at /text, and Markdown at /markdown. Every request re-reads the
+// spreadsheet, overrides, and template, so refreshing the browser shows the
+// latest edits. Nothing is written to -output.
+func serveNotice(addr string, opts noticeOptions) error {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		variant, ok := previewFormats[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		previewOpts := opts
+		previewOpts.Format = variant.format
+		// A custom template is written for one format; the others use the
+		// built-in templates
+		if variant.format != opts.Format {
+			previewOpts.TemplatePath = ""
+		}
+
+		var buf bytes.Buffer
+		notice, err := renderNotice(previewOpts, &buf)
+		if err != nil {
+			fmt.Printf("Error %v\n", err)
+			http.Error(w, "Error "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if notice == nil {
+			http.Error(w, "No future events found in the spreadsheet.", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", variant.contentType)
+		w.Write(buf.Bytes())
+	}
+
+	url := addr
+	if strings.HasPrefix(url, ":") {
+		url = "localhost" + url
+	}
+	fmt.Printf("Previewing the notice at http://%s/ (also /text and /markdown); Ctrl-C to stop\n", url)
+	return http.ListenAndServe(addr, http.HandlerFunc(handler))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readTemplateData parses -template-data, given either inline as a JSON
+// object or as the path of a file holding one. Its keys are available to
+// templates as .Extra.<Key>, e.g. {"ZoomLink": "https://..."} as
+// {{.Extra.ZoomLink}}.
+func readTemplateData(value string) (map[string]any, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var err error
+		data, err = os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var extra map[string]any
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, fmt.Errorf("template data must be a JSON object: %v", err)
+	}
+	return extra, nil
+}
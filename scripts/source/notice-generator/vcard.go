@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+)
+
+// VCard is a minimal vCard 3.0 contact for the meeting speaker.
+type VCard struct {
+	Name         string
+	Organization string
+	Email        string
+}
+
+// String renders the card with CRLF line endings and folded long lines, as
+// required by RFC 2426.
+func (c VCard) String() string {
+	var lines []string
+	lines = append(lines, "BEGIN:VCARD", "VERSION:3.0")
+
+	// N is structured as Family;Given;Additional;Prefix;Suffix
+	fields := strings.Fields(c.Name)
+	family, given := "", c.Name
+	if len(fields) > 1 {
+		family = fields[len(fields)-1]
+		given = strings.Join(fields[:len(fields)-1], " ")
+	}
+	lines = append(lines, "N:"+escapeVCard(family)+";"+escapeVCard(given)+";;;")
+	lines = append(lines, "FN:"+escapeVCard(c.Name))
+
+	if c.Organization != "" {
+		lines = append(lines, "ORG:"+escapeVCard(c.Organization))
+	}
+	if c.Email != "" {
+		lines = append(lines, "EMAIL;TYPE=INTERNET:"+escapeVCard(c.Email))
+	}
+	lines = append(lines, "END:VCARD")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldVCardLine(line))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+func escapeVCard(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// foldVCardLine splits lines longer than 75 octets, continuing each with a
+// leading space. It never splits inside a UTF-8 sequence.
+func foldVCardLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long the files must stay unchanged before regenerating,
+// so a burst of saves (or an editor writing in several steps) runs once
+const watchSettle = time.Second
+
+// watchSpreadsheet regenerates the notice each time the spreadsheet or a
+// -calendar file is saved, until interrupted. Errors are reported and
+// watching continues, since the next save will usually fix them.
+//
+// The directories holding the files are watched rather than the files, since
+// editors like Excel and LibreOffice save by writing a new file and renaming
+// it over the old one, which ends a watch on the file itself.
+func watchSpreadsheet(opts noticeOptions) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watched := make(map[string]bool) // cleaned absolute paths of the files
+	for _, path := range opts.Calendars {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		watched[abs] = true
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			return fmt.Errorf("watching %s: %w", filepath.Dir(abs), err)
+		}
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	fmt.Printf("Watching %s for changes (Ctrl-C to stop)\n", strings.Join(opts.Calendars, ", "))
+
+	// settle fires once no change has been seen for watchSettle
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println("\nStopped watching")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if watched[filepath.Clean(event.Name)] {
+				settle.Reset(watchSettle)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "[%s] Warning: %v\n", time.Now().Format("15:04:05"), err)
+		case now := <-settle.C:
+			stamp := now.Format("15:04:05")
+			if missing := missingFile(opts.Calendars); missing != nil {
+				fmt.Fprintf(os.Stderr, "[%s] Waiting for %v\n", stamp, missing)
+				continue
+			}
+			fmt.Printf("[%s] Calendar changed, regenerating\n", stamp)
+			if _, err := generateNotice(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "[%s] Error %v\n", stamp, err)
+			}
+		}
+	}
+}
+
+// missingFile returns the error for the first file that can't be read, or
+// nil if all of them can.
+func missingFile(paths []string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}